@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hymkor/make-scoop-manifest/internal/flag"
+	"github.com/hymkor/make-scoop-manifest/internal/github"
+	"github.com/hymkor/make-scoop-manifest/scoop"
+)
+
+// bucketConfig is the config file of "bucket CONFIG.json"
+type bucketConfig struct {
+	// Args are the options for all the apps
+	Args []string     `json:"args"`
+	Apps []*bucketApp `json:"apps"`
+}
+
+// bucketApp is an app of the bucket
+type bucketApp struct {
+	Repo string `json:"repo"`
+	// Output is the manifest file relative to the config file
+	Output string `json:"output"`
+	// Template is used when Output does not exist yet
+	Template json.RawMessage `json:"template"`
+	// Args are the options only for the app
+	Args []string `json:"args"`
+}
+
+// appFlagSet returns the flags which the apps in the config file can have.
+// Their defaults are the fields of o, and they are set into o by apply.
+func appFlagSet(o *scoop.Options) (flags *flag.FlagSet, apply func()) {
+	flags = &flag.FlagSet{}
+	anyCPU := flags.Bool("anycpu", o.AnyCPU, "Do not use \"architecture\" of the manifest")
+	extractDir := flags.Bool("p", o.ExtractDir, "Specify the parent directory of *.exe into \"extract_dir\"")
+	keywords32 := flags.String("32", o.Keywords32, "The words for 32bit")
+	keywords64 := flags.String("64", o.Keywords64, "The words for 64bit")
+	keywordsArm64 := flags.String("arm64", o.KeywordsArm64, "The words for arm64")
+	peCheck := flags.Bool("pecheck", o.PECheck, "Confirm the architecture with the headers of the executables")
+	strict := flags.Bool("strict", o.Strict, "With -pecheck, fail when the architectures differ")
+	license := flags.String("license", o.License, "The value of \"license\"")
+	description := flags.String("description", o.Description, "The value of \"description\"")
+	binPattern := flags.String("binpattern", o.BinPattern, "The pattern for executables")
+	ignoreWords := flags.String("ignore", o.IgnoreWords, "Ignore the assets whose names contain these words")
+	noAutoUpdate := flags.Bool("noautoupdate", o.NoAutoUpdate, "Disable autoupdate")
+	forge := flags.String("forge", o.Forge, "The kind of the forge")
+	tagRegex := flags.String("tagregex", o.TagRegex, "The regular expression to get the version from the tag")
+	latest := flags.Bool("latest", o.Latest, "Use the release marked as \"Latest\"")
+	prerelease := flags.Bool("prerelease", o.Prerelease, "Use the newest release including pre-releases")
+	return flags, func() {
+		o.AnyCPU = *anyCPU
+		o.ExtractDir = *extractDir
+		o.Keywords32 = *keywords32
+		o.Keywords64 = *keywords64
+		o.KeywordsArm64 = *keywordsArm64
+		o.PECheck = *peCheck
+		o.Strict = *strict
+		o.License = *license
+		o.Description = *description
+		o.BinPattern = *binPattern
+		o.IgnoreWords = *ignoreWords
+		o.NoAutoUpdate = *noAutoUpdate
+		o.Forge = *forge
+		o.TagRegex = *tagRegex
+		o.Latest = *latest
+		o.Prerelease = *prerelease
+	}
+}
+
+// bucketResult is a row of the summary table
+type bucketResult struct {
+	app     *bucketApp
+	status  string
+	version string
+	err     error
+}
+
+// appOptions returns the options of the app. The args of the config file,
+// the ones of the app and the options of the command line are parsed in
+// this order, so that the later ones override the earlier ones.
+func appOptions(base *scoop.Options, config *bucketConfig, app *bucketApp) (*scoop.Options, error) {
+	options := *base
+	flags, apply := appFlagSet(&options)
+	if err := flags.Parse(slices.Concat(config.Args, app.Args)); err != nil {
+		return nil, err
+	}
+	if len(flags.Args()) > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	if err := flags.Parse(flag.Given(flags)); err != nil {
+		return nil, err
+	}
+	apply()
+	return &options, nil
+}
+
+// updateBucketApp writes the manifest of the app and returns its version
+// and whether the file is written. When the output exists, only "version",
+// "url" and "hash" of it are replaced as -update does.
+func updateBucketApp(ctx context.Context, base *scoop.Options, config *bucketConfig, app *bucketApp, dir string) (string, bool, error) {
+	options, err := appOptions(base, config, app)
+	if err != nil {
+		return "", false, err
+	}
+
+	output := filepath.Join(dir, filepath.FromSlash(app.Output))
+	source, err := os.ReadFile(output)
+	if err == nil {
+		var current scoop.Manifest
+		if err := json.Unmarshal(source, &current); err != nil {
+			return "", false, fmt.Errorf("%s: %w", output, err)
+		}
+		if len(current.UrlForAnyCPU) > 0 && current.Archtectures == nil {
+			options.AnyCPU = true
+		}
+		options.Template = source
+		return updateManifest(ctx, scoop.New(options), app.Repo, output, source, current.Version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", false, err
+	}
+	options.Template = app.Template
+	g := scoop.New(options)
+	release, err := g.Release(ctx, app.Repo)
+	if err != nil {
+		return "", false, err
+	}
+	manifest, err := g.GenerateRelease(ctx, release)
+	if err != nil {
+		return "", false, err
+	}
+	jsonBin, err := manifest.Format()
+	if err != nil {
+		return "", false, err
+	}
+	warnInvalid(output, jsonBin)
+	fmt.Fprintf(os.Stderr, "Create %s for %s\n", output, manifest.Version)
+	return manifest.Version, true, os.WriteFile(output, jsonBin, 0644)
+}
+
+// bucketCommand runs "bucket CONFIG.json", which regenerates all the
+// manifests of the apps listed in the config file, and prints the summary.
+// The apps are processed one by one with the same HTTP client, and the rest
+// are not requested after the rate limit of the API is exceeded.
+func bucketCommand(args []string, stdout io.Writer, client *http.Client) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: %s bucket CONFIG.json", os.Args[0])
+	}
+	configBin, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	var config bucketConfig
+	if err := json.Unmarshal(configBin, &config); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	dir := filepath.Dir(args[0])
+	ctx := context.Background()
+	base := newOptions(nil, nil, client)
+	if base.Client == nil {
+		base.Client = http.DefaultClient
+	}
+
+	var results []*bucketResult
+	var rateLimit *github.RateLimitError
+	for _, app := range config.Apps {
+		r := &bucketResult{app: app}
+		results = append(results, r)
+		if rateLimit != nil {
+			r.status, r.err = "failed", rateLimit
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "== %s ==\n", app.Output)
+		var updated bool
+		r.version, updated, r.err = updateBucketApp(ctx, base, &config, app, dir)
+		switch {
+		case r.err != nil:
+			r.status = "failed"
+			fmt.Fprintf(os.Stderr, "%s: %s\n", app.Output, r.err.Error())
+			errors.As(r.err, &rateLimit)
+		case !updated:
+			r.status = "unchanged"
+		default:
+			r.status = "updated"
+		}
+	}
+
+	var failed int
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OUTPUT\tREPOSITORY\tSTATUS\tVERSION")
+	for _, r := range results {
+		detail := r.version
+		if r.err != nil {
+			detail = r.err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.app.Output, r.app.Repo, r.status, detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d failed", failed, len(results))
+	}
+	return nil
+}
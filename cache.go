@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hymkor/make-scoop-manifest/internal/cache"
+)
+
+// defaultPruneDays is the days the entries of the cache are kept
+// without being used by "cache prune"
+const defaultPruneDays = 30
+
+// cacheCommand runs "cache prune [DAYS]", which removes the entries of
+// the cache not used for DAYS days.
+func cacheCommand(args []string) error {
+	if len(args) < 1 || args[0] != "prune" {
+		return fmt.Errorf("usage: %s cache prune [DAYS]", os.Args[0])
+	}
+	days := defaultPruneDays
+	if len(args) >= 2 {
+		var err error
+		days, err = strconv.Atoi(args[1])
+		if err != nil || days < 0 {
+			return fmt.Errorf("%s: DAYS must be a number not less than 0", args[1])
+		}
+	}
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return err
+	}
+	count, err := cache.New(dir).Prune(time.Duration(days) * 24 * time.Hour)
+	fmt.Fprintf(os.Stderr, "%d entries removed from %s\n", count, dir)
+	return err
+}
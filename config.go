@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/flag"
+	"github.com/hymkor/make-scoop-manifest/internal/gitdir"
+)
+
+// configName is the name of the configuration file in the top directory
+// of the repository
+const configName = ".make-scoop-manifest.json"
+
+// repoConfig is the configuration file of the repository
+type repoConfig struct {
+	// Args are the options given before the ones of the command line
+	Args []string `json:"args"`
+	// Template is used when no template is given with the command line
+	Template json.RawMessage `json:"template"`
+}
+
+// configTemplate is Template of the configuration file read
+var configTemplate []byte
+
+// readRepoConfig reads the configuration file in the top directory of
+// the repository containing dir. It returns nil when it does not exist.
+func readRepoConfig(dir string) (*repoConfig, string, error) {
+	root, err := gitdir.Root(dir)
+	if err != nil || root == "" {
+		return nil, "", err
+	}
+	fname := filepath.Join(root, configName)
+	bin, err := os.ReadFile(fname)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	var config repoConfig
+	if err := json.Unmarshal(bin, &config); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", fname, err)
+	}
+	return &config, fname, nil
+}
+
+// applyRepoConfig parses the options of the configuration file of the
+// repository in the current directory and then args of the command line
+// again, so that the options of the command line override the ones of
+// the file. It does nothing with -noconfig.
+func applyRepoConfig(args []string) error {
+	if *flagNoConfig {
+		return nil
+	}
+	config, fname, err := readRepoConfig(".")
+	if err != nil || config == nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Read config:", fname)
+
+	flag.Reset()
+	if err := flag.ParseArgs(config.Args); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	if len(flag.Args()) > 0 {
+		return fmt.Errorf("%s: unexpected arguments: %s", fname, strings.Join(flag.Args(), " "))
+	}
+	if err := flag.ParseArgs(args); err != nil {
+		return err
+	}
+	if len(config.Template) > 0 {
+		configTemplate = config.Template
+	}
+	return nil
+}
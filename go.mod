@@ -1,3 +1,22 @@
 module github.com/hymkor/make-scoop-manifest
 
-go 1.19
+go 1.23
+
+require (
+	github.com/bodgit/sevenzip v1.6.0
+	github.com/klauspost/compress v1.17.9
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.3
+	github.com/ulikunitz/xz v0.5.12
+	golang.org/x/text v0.20.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/bodgit/plumbing v1.3.0 // indirect
+	github.com/bodgit/windows v1.0.1 // indirect
+	github.com/hashicorp/errwrap v1.0.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
+	go4.org v0.0.0-20200411211856-f5505b9728dd // indirect
+)
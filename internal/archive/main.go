@@ -0,0 +1,212 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// kinds are the supported extensions in the order of preference.
+var kinds = []string{
+	".zip",
+	".7z",
+	".tar",
+	".tar.gz", ".tgz",
+	".tar.xz", ".txz",
+	".tar.zst", ".tzst",
+	".tar.bz2", ".tbz2",
+	".msi",
+	".exe",
+}
+
+// Kind returns the extension of name which this package recognizes
+// (for example ".tar.gz") or "" when the file is not supported.
+func Kind(name string) string {
+	lower := strings.ToLower(name)
+	kind := ""
+	for _, k := range kinds {
+		if strings.HasSuffix(lower, k) && len(k) > len(kind) {
+			kind = k
+		}
+	}
+	return kind
+}
+
+// Rank returns the preference of the kind. The smaller is preferred.
+func Rank(kind string) int {
+	if i := slices.Index(kinds, kind); i >= 0 {
+		return i
+	}
+	return len(kinds)
+}
+
+// IsArchive reports whether the kind is an archive whose entries can be listed.
+// ".msi" and ".exe" are installable but are not archives for this package.
+func IsArchive(kind string) bool {
+	return kind != "" && kind != ".msi" && kind != ".exe"
+}
+
+// Opener opens the content of an entry. The reader is valid only while
+// the WalkFunc which received it is running.
+type Opener func() (io.ReadCloser, error)
+
+// WalkFunc is called for each regular file in an archive.
+// The name is always separated with slashes.
+type WalkFunc func(name string, open Opener) error
+
+func normalize(name string) string {
+	return strings.ReplaceAll(name, `\`, "/")
+}
+
+// Walk calls fn for each regular file in the archive fname.
+func Walk(fname string, fn WalkFunc) error {
+	kind := Kind(fname)
+	if kind == "" {
+		return fmt.Errorf("%s: unsupported file type", fname)
+	}
+	if !IsArchive(kind) {
+		return fmt.Errorf("%s: not an archive", fname)
+	}
+	fd, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	if IsTar(kind) {
+		return WalkTar(fd, kind, fn)
+	}
+	stat, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+	return WalkReaderAt(fd, stat.Size(), kind, fn)
+}
+
+// IsTar reports whether the kind is a (compressed) tar archive,
+// which can be walked as a stream with WalkTar.
+func IsTar(kind string) bool {
+	return IsArchive(kind) && kind != ".zip" && kind != ".7z"
+}
+
+// WalkReaderAt calls fn for each regular file in the zip or 7z archive r
+// of size bytes. Only the directory of the archive is read unless fn opens
+// the entries, so r may read a remote file with range requests.
+func WalkReaderAt(r io.ReaderAt, size int64, kind string, fn WalkFunc) error {
+	switch kind {
+	case ".zip":
+		return walkZip(r, size, fn)
+	case ".7z":
+		return walk7z(r, size, fn)
+	}
+	return fmt.Errorf("%s: not a zip or 7z archive", kind)
+}
+
+func walkZip(r io.ReaderAt, size int64, fn WalkFunc) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := fn(normalize(f.Name), f.Open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walk7z(r io.ReaderAt, size int64, fn WalkFunc) error {
+	zr, err := sevenzip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := fn(normalize(f.Name), f.Open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type closeFunc func()
+
+func (c closeFunc) Close() error {
+	c()
+	return nil
+}
+
+var noClose = closeFunc(func() {})
+
+func decompress(r io.Reader, kind string) (io.Reader, io.Closer, error) {
+	switch kind {
+	case ".tar":
+		return r, noClose, nil
+	case ".tar.gz", ".tgz":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr, nil
+	case ".tar.xz", ".txz":
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return xr, noClose, nil
+	case ".tar.zst", ".tzst":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, closeFunc(zr.Close), nil
+	case ".tar.bz2", ".tbz2":
+		return bzip2.NewReader(r), noClose, nil
+	}
+	return nil, nil, fmt.Errorf("%s: not a tar archive", kind)
+}
+
+// WalkTar calls fn for each regular file in the (compressed) tar stream r.
+// kind is the value Kind returns for the file name.
+func WalkTar(r io.Reader, kind string, fn WalkFunc) error {
+	plain, closer, err := decompress(r, kind)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	tr := tar.NewReader(plain)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		open := func() (io.ReadCloser, error) {
+			return io.NopCloser(tr), nil
+		}
+		if err := fn(normalize(strings.TrimPrefix(hdr.Name, "./")), open); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func makeZip(t *testing.T, fname string, names []string) {
+	t.Helper()
+	fd, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer fd.Close()
+	zw := zip.NewWriter(fd)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		w.Write([]byte(name))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func makeTarGz(t *testing.T, fname string, names []string) {
+	t.Helper()
+	fd, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer fd.Close()
+	zw := gzip.NewWriter(fd)
+	tw := tar.NewWriter(zw)
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(name)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err.Error())
+		}
+		tw.Write([]byte(name))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestKind(t *testing.T) {
+	for name, expect := range map[string]string{
+		"foo-1.0-windows-amd64.zip":    ".zip",
+		"foo-1.0-windows-amd64.tar.gz": ".tar.gz",
+		"foo-1.0-windows-amd64.tgz":    ".tgz",
+		"foo-1.0-windows-amd64.7Z":     ".7z",
+		"foo.exe":                      ".exe",
+		"checksums.txt":                "",
+	} {
+		if result := Kind(name); result != expect {
+			t.Fatalf("%s: expect %#v, but %#v", name, expect, result)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"foo/foo.exe", "foo/README.md"}
+
+	zipName := filepath.Join(dir, "foo.zip")
+	makeZip(t, zipName, names)
+	tgzName := filepath.Join(dir, "foo.tar.gz")
+	makeTarGz(t, tgzName, names)
+
+	for _, fname := range []string{zipName, tgzName} {
+		var result []string
+		err := Walk(fname, func(name string, open Opener) error {
+			r, err := open()
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			result = append(result, name)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !slices.Equal(result, names) {
+			t.Fatalf("%s: expect %#v, but %#v", fname, names, result)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package cache keeps the hashes and the file lists of the downloaded
+// assets, so that they are not downloaded again while they are not modified.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Entry is the record of a downloaded asset
+type Entry struct {
+	Url          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Size         int64  `json:"size"`
+	Sha256       string `json:"sha256"`
+	// Files are all the files in the archive. The executables are
+	// chosen from them with the patterns of the time they are used.
+	Files []string `json:"files,omitempty"`
+	// Machines are the architectures of the executables read from
+	// their headers with -pecheck.
+	Machines map[string]string `json:"machines,omitempty"`
+	// PECheck tells that the headers are read, even when Machines is
+	// empty because the asset has no PE executables.
+	PECheck bool `json:"pecheck,omitempty"`
+	// License is the text of the license file in the archive
+	License string    `json:"license,omitempty"`
+	Used    time.Time `json:"used"`
+}
+
+// Cache is the directory which has an entry file for each URL
+type Cache struct {
+	dir string
+}
+
+// DefaultDir returns make-scoop-manifest under the cache directory
+// of the user ($XDG_CACHE_HOME, %LocalAppData% and so on)
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "make-scoop-manifest"), nil
+}
+
+// New returns the cache in dir. The directory is made when an entry is put.
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+func (c *Cache) path(url string) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(url))))
+}
+
+// Get returns the entry for the URL.
+func (c *Cache) Get(url string) (*Entry, bool) {
+	bin, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return nil, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(bin, &entry); err != nil || entry.Url != url {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Put records the entry with the time it is used.
+// It is also called to update the time when the entry is used again.
+func (c *Cache) Put(entry *Entry) error {
+	entry.Used = time.Now()
+	bin, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	// Write into a temporary file and rename it so that the other
+	// processes never read the entry being written.
+	tmp, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(bin)
+	if err1 := tmp.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(entry.Url))
+}
+
+// Prune removes the entries which are not used for age and
+// the broken files. It returns the number of the removed files.
+func (c *Cache) Prune(age time.Duration) (int, error) {
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	limit := time.Now().Add(-age)
+	count := 0
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		fname := filepath.Join(c.dir, e.Name())
+		if !strings.HasSuffix(e.Name(), ".json") {
+			// The temporary files left by the interrupted processes
+			if info, err := e.Info(); err == nil && info.ModTime().Before(limit) {
+				if os.Remove(fname) == nil {
+					count++
+				}
+			}
+			continue
+		}
+		var entry Entry
+		bin, err := os.ReadFile(fname)
+		if err == nil {
+			err = json.Unmarshal(bin, &entry)
+		}
+		if err == nil && !entry.Used.Before(limit) {
+			continue
+		}
+		if err := os.Remove(fname); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
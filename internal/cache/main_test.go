@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPutGet(t *testing.T) {
+	c := New(t.TempDir())
+	const url = "https://example.com/foo.zip"
+	if _, ok := c.Get(url); ok {
+		t.Fatal("expect no entry")
+	}
+	err := c.Put(&Entry{
+		Url:    url,
+		ETag:   `"abc"`,
+		Size:   3,
+		Sha256: "0123",
+		Files:  []string{"foo/foo.exe"},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	entry, ok := c.Get(url)
+	if !ok {
+		t.Fatal("expect the entry")
+	}
+	if entry.ETag != `"abc"` || entry.Sha256 != "0123" || !slices.Equal(entry.Files, []string{"foo/foo.exe"}) {
+		t.Fatalf("unexpected entry: %#v", entry)
+	}
+	if _, ok := c.Get(url + "x"); ok {
+		t.Fatal("expect no entry for the other URL")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	c := New(t.TempDir())
+	for _, url := range []string{"https://example.com/a.zip", "https://example.com/b.zip"} {
+		if err := c.Put(&Entry{Url: url}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if n, err := c.Prune(time.Hour); err != nil || n != 0 {
+		t.Fatalf("expect 0 removed, but %d (%v)", n, err)
+	}
+	if n, err := c.Prune(0); err != nil || n != 2 {
+		t.Fatalf("expect 2 removed, but %d (%v)", n, err)
+	}
+	if _, ok := c.Get("https://example.com/a.zip"); ok {
+		t.Fatal("expect the entry is removed")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -33,6 +34,10 @@ func (s *_StringFlag) parse(args []string, log io.Writer) ([]string, error) {
 	return args[1:], nil
 }
 
+func (s *_StringFlag) reset() {
+	s._value = s._default
+}
+
 func (s *_StringFlag) usage() string {
 	var u strings.Builder
 	fmt.Fprintf(&u, "  %s string", s._name)
@@ -58,6 +63,10 @@ func (b *_BoolFlag) parse(args []string, log io.Writer) ([]string, error) {
 	return args, nil
 }
 
+func (b *_BoolFlag) reset() {
+	b._value = b._default
+}
+
 func (b *_BoolFlag) usage() string {
 	var u strings.Builder
 	fmt.Fprintf(&u, "  %s", b._name)
@@ -70,14 +79,53 @@ func (b *_BoolFlag) usage() string {
 	return u.String()
 }
 
+type _IntFlag struct {
+	_name    string
+	_default int
+	_usage   string
+	_value   int
+}
+
+func (i *_IntFlag) parse(args []string, log io.Writer) ([]string, error) {
+	if len(args) <= 0 {
+		return nil, errors.New("too few arguments")
+	}
+	value, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+	i._value = value
+	fmt.Fprintf(Debug, "%s: set %#v\n", i._name, i._value)
+	return args[1:], nil
+}
+
+func (i *_IntFlag) reset() {
+	i._value = i._default
+}
+
+func (i *_IntFlag) usage() string {
+	var u strings.Builder
+	fmt.Fprintf(&u, "  %s int", i._name)
+	if u.Len() <= 6 {
+		u.WriteByte('\t')
+	} else {
+		u.WriteString("\n    \t")
+	}
+	u.WriteString(i._usage)
+	return u.String()
+}
+
 type _Flag interface {
 	parse([]string, io.Writer) ([]string, error)
 	usage() string
+	reset()
 }
 
 type FlagSet struct {
 	flags      map[string]_Flag
 	nonOptions []string
+	// given are the flags parsed since Reset with their values
+	given [][]string
 }
 
 func (f *FlagSet) String(name, defaults, usage string) *string {
@@ -109,10 +157,46 @@ func (f *FlagSet) Bool(name string, defaults bool, usage string) *bool {
 	return &b._value
 }
 
+func (f *FlagSet) Int(name string, defaults int, usage string) *int {
+	i := &_IntFlag{
+		_name:    "-" + name,
+		_default: defaults,
+		_usage:   usage,
+		_value:   defaults,
+	}
+	if f.flags == nil {
+		f.flags = make(map[string]_Flag)
+	}
+	f.flags[name] = i
+	return &i._value
+}
+
 func (f *FlagSet) Args() []string {
 	return f.nonOptions
 }
 
+// Reset sets all the flags to their defaults and forgets the non-option arguments.
+func (f *FlagSet) Reset() {
+	for _, value := range f.flags {
+		value.reset()
+	}
+	f.nonOptions = nil
+	f.given = nil
+}
+
+// Given returns the flags parsed since Reset with their values in the
+// order of the arguments, only the ones which known also has. They are
+// parsed by known again to override the flags given to it before.
+func (f *FlagSet) Given(known *FlagSet) []string {
+	var args []string
+	for _, given := range f.given {
+		if _, ok := known.flags[given[0][1:]]; ok {
+			args = append(args, given...)
+		}
+	}
+	return args
+}
+
 func (f *FlagSet) usage() {
 	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
 	for _, value := range f.flags {
@@ -139,11 +223,12 @@ func (f *FlagSet) Parse(args []string) error {
 			}
 			return fmt.Errorf("flag provided but not defined: %s", name)
 		}
-		var err error
-		args, err = o.parse(args, os.Stderr)
+		rest, err := o.parse(args, os.Stderr)
 		if err != nil {
 			return fmt.Errorf("%s: %w", name, err)
 		}
+		f.given = append(f.given, append([]string{name}, args[:len(args)-len(rest)]...))
+		args = rest
 	}
 	fmt.Fprintf(Debug, "Non Option args: %#v\n", f.nonOptions)
 	return nil
@@ -159,10 +244,30 @@ func Bool(name string, defaults bool, usage string) *bool {
 	return globalFlag.Bool(name, defaults, usage)
 }
 
+func Int(name string, defaults int, usage string) *int {
+	return globalFlag.Int(name, defaults, usage)
+}
+
 func Args() []string {
 	return globalFlag.Args()
 }
 
+func Reset() {
+	globalFlag.Reset()
+}
+
+// Given returns the flags of the command line which known also has
+// with their values.
+func Given(known *FlagSet) []string {
+	return globalFlag.Given(known)
+}
+
+// ParseArgs parses args as the command line. The values given before
+// are kept unless Reset is called.
+func ParseArgs(args []string) error {
+	return globalFlag.Parse(args)
+}
+
 func Parse() {
 	err := globalFlag.Parse(os.Args[1:])
 	if err == nil {
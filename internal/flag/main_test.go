@@ -1,6 +1,7 @@
 package flag
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -37,3 +38,63 @@ func TestString(t *testing.T) {
 		t.Fatalf("expect %#v, but (*FlagSet) Bool() returns %#v", true, *bool1)
 	}
 }
+
+func TestInt(t *testing.T) {
+	var fs FlagSet
+
+	int1 := fs.Int("n", 0, "usage")
+
+	if err := fs.Parse([]string{"-n", "3", "ihihi"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if *int1 != 3 {
+		t.Fatalf("expect %#v, but (*FlagSet) Int() returns %#v", 3, *int1)
+	}
+	if err := fs.Parse([]string{"-n", "x"}); err == nil {
+		t.Fatal("expect error, but (*FlagSet) Parse() returns nil")
+	}
+}
+
+func TestReset(t *testing.T) {
+	var fs FlagSet
+
+	str1 := fs.String("s", "default", "usage")
+	bool1 := fs.Bool("b", false, "usage")
+
+	if err := fs.Parse([]string{"-s", "ahaha", "-b", "ihihi"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	fs.Reset()
+	if *str1 != "default" || *bool1 != false || len(fs.Args()) != 0 {
+		t.Fatalf("(*FlagSet) Reset() leaves %#v, %#v, %#v", *str1, *bool1, fs.Args())
+	}
+}
+
+func TestGiven(t *testing.T) {
+	var fs FlagSet
+	fs.String("s", "", "usage")
+	fs.Bool("b", false, "usage")
+	fs.Int("n", 0, "usage")
+
+	var known FlagSet
+	str1 := known.String("s", "default", "usage")
+	known.Bool("b", false, "usage")
+
+	if err := fs.Parse([]string{"-n", "3", "-s", "ahaha", "ihihi", "-b", "-s", "ufufu"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	given := fs.Given(&known)
+	if expect := []string{"-s", "ahaha", "-b", "-s", "ufufu"}; !slices.Equal(given, expect) {
+		t.Fatalf("expect %#v, but (*FlagSet) Given() returns %#v", expect, given)
+	}
+	if err := known.Parse(append([]string{"-s", "ehehe"}, given...)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if *str1 != "ufufu" {
+		t.Fatalf("expect %#v, but %#v", "ufufu", *str1)
+	}
+	fs.Reset()
+	if given := fs.Given(&known); len(given) != 0 {
+		t.Fatalf("(*FlagSet) Reset() leaves %#v", given)
+	}
+}
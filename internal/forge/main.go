@@ -0,0 +1,145 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string
+	Url  string
+	// Digest is the hash the forge publishes as "ALGORITHM:HEX"
+	// (for example "sha256:0123...") or "" when it is not published.
+	Digest string
+}
+
+// Release is a release on the forge in the common form for all the forges.
+type Release struct {
+	TagName    string
+	Assets     []*Asset
+	Draft      bool
+	Prerelease bool
+}
+
+// Description is the information of a repository.
+type Description struct {
+	Description string
+	// License is the SPDX identifier of the license, or its name when
+	// the forge does not know the identifier
+	License string
+	// LicenseUrl is the URL of the license file in the repository
+	LicenseUrl string
+	// LicenseText is the license file, which is read only when the forge
+	// does not know the SPDX identifier. Only GitHub sets it now.
+	LicenseText []byte
+}
+
+// CheckVer is the object form of "checkver" of the manifest.
+type CheckVer struct {
+	Github   string `json:"github,omitempty"`
+	Url      string `json:"url,omitempty"`
+	JsonPath string `json:"jsonpath,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+}
+
+// Provider is the service which hosts repositories and their releases
+// such as GitHub, GitLab and Gitea/Forgejo.
+type Provider interface {
+	// Name returns the name of the forge shown in messages.
+	Name() string
+	// ListReleases returns all the releases newest first.
+	ListReleases(ctx context.Context, owner, repo string, log io.Writer) ([]*Release, error)
+	// FindRelease returns the first release for which match returns true.
+	FindRelease(ctx context.Context, owner, repo string, log io.Writer, match func(*Release) bool) (*Release, error)
+	// GetLatestRelease returns the release the forge regards as the latest.
+	GetLatestRelease(ctx context.Context, owner, repo string, log io.Writer) (*Release, error)
+	// GetReleaseByTag returns the release for the tag.
+	GetReleaseByTag(ctx context.Context, owner, repo, tag string, log io.Writer) (*Release, error)
+	// GetDescription returns the description and the license of the repository.
+	GetDescription(ctx context.Context, owner, repo string, log io.Writer) (*Description, error)
+	// Homepage returns the URL of the repository for humans.
+	Homepage(owner, repo string) string
+	// ReleasesApi returns the URL of the API listing the releases newest first.
+	ReleasesApi(owner, repo string) string
+	// CheckVer returns the value of "checkver" for the repository.
+	// regex is the pattern for the tag with the group "version",
+	// or "" for the default one.
+	CheckVer(owner, repo, regex string) any
+}
+
+// Response is the result of Get.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Next is the URL of the next page given by the Link header.
+	Next string
+}
+
+var rxNextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// Get sends GET request to url with header by client.
+// When client is nil, http.DefaultClient is used.
+func Get(ctx context.Context, client *http.Client, url string, header http.Header, log io.Writer) (*Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	fmt.Fprintln(log, "Get:", url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var next string
+	if m := rxNextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+		next = m[1]
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Next:       next,
+	}, nil
+}
+
+// FindInPages walks the pages from url and returns the first release
+// for which match returns true. parse converts a page to the releases.
+func FindInPages(url string, get func(string) (*Response, error), parse func([]byte) ([]*Release, error), match func(*Release) bool) (*Release, error) {
+	for url != "" {
+		resp, err := get(url)
+		if err != nil {
+			return nil, err
+		}
+		page, err := parse(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page {
+			if match(r) {
+				return r, nil
+			}
+		}
+		url = resp.Next
+	}
+	return nil, ErrNoReleases
+}
+
+// ErrNoReleases is returned when no release matches.
+var ErrNoReleases = errors.New("no releases")
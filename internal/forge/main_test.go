@@ -0,0 +1,62 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFindInPages(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", `<`+server.URL+`/?page=1>; rel="prev", <`+server.URL+`/?page=2>; rel="next"`)
+			w.Write([]byte(`["v3.0.0"]`))
+		case "2":
+			w.Write([]byte(`["v2.0.0","v1.0.0"]`))
+		}
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	var pages []string
+	get := func(url string) (*Response, error) {
+		pages = append(pages, url)
+		return Get(context.Background(), server.Client(), url, header, io.Discard)
+	}
+	parse := func(bin []byte) ([]*Release, error) {
+		var tags []string
+		if err := json.Unmarshal(bin, &tags); err != nil {
+			return nil, err
+		}
+		var releases []*Release
+		for _, tag := range tags {
+			releases = append(releases, &Release{TagName: tag})
+		}
+		return releases, nil
+	}
+
+	r, err := FindInPages(server.URL+"/", get, parse, func(r *Release) bool { return r.TagName == "v2.0.0" })
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if r.TagName != "v2.0.0" || len(pages) != 2 || pages[1] != server.URL+"/?page=2" {
+		t.Fatalf("expect v2.0.0 on the next page, but %s in %v", r.TagName, pages)
+	}
+
+	pages = nil
+	_, err = FindInPages(server.URL+"/", get, parse, func(r *Release) bool { return false })
+	if !errors.Is(err, ErrNoReleases) || len(pages) != 2 {
+		t.Fatalf("expect ErrNoReleases after all the pages, but %v in %v", err, pages)
+	}
+}
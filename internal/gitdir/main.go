@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -43,31 +45,58 @@ func listUpRemoteBranch(tee io.Writer) ([]string, error) {
 }
 
 var (
-	rxSshUrl   = regexp.MustCompile(`Push +URL: \w+@github.com:([\w\.-]+)/([\w\.-]+)\.git`)
-	rxHttpsUrl = regexp.MustCompile(`Push +URL: https://github.com/([\w\.-]+)/([\w\.-]+?)(\.git)?$`)
+	rxSshUrl   = regexp.MustCompile(`Push +URL: \w+@([\w\.-]+):([\w\./-]+)/([\w\.-]+?)(\.git)?$`)
+	rxHttpsUrl = regexp.MustCompile(`Push +URL: https://([\w\.-]+(?::\d+)?)/([\w\./-]+)/([\w\.-]+?)(\.git)?$`)
 )
 
-func GetNameAndRepo(tee io.Writer) (string, string, error) {
+// GetNameAndRepo returns the host, the owner and the name of the repository
+// from the push URL of the first remote. The owner may contain slashes
+// for the nested groups of GitLab.
+func GetNameAndRepo(tee io.Writer) (string, string, string, error) {
 	branch, err := listUpRemoteBranch(tee)
 	if err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 	if len(branch) < 1 {
-		return "", "", errors.New("remote branch not found")
+		return "", "", "", errors.New("remote branch not found")
 	}
-	var user, repo string
+	var host, user, repo string
 	quote([]string{"git", "remote", "show", "-n", branch[0]}, tee, func(line string) error {
 		if m := rxSshUrl.FindStringSubmatch(line); m != nil {
-			user = m[1]
-			repo = m[2]
+			host = m[1]
+			user = m[2]
+			repo = m[3]
 			return io.EOF
 		}
 		if m := rxHttpsUrl.FindStringSubmatch(line); m != nil {
-			user = m[1]
-			repo = m[2]
+			host = m[1]
+			user = m[2]
+			repo = m[3]
 			return io.EOF
 		}
 		return nil
 	})
-	return user, repo, nil
+	return host, user, repo, nil
+}
+
+// Root returns the top directory of the working tree containing dir,
+// where ".git" is. ".git" may be a file for worktrees and submodules.
+// It returns "" when dir is not in a working tree.
+func Root(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
 }
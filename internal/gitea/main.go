@@ -0,0 +1,202 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// TokenFromEnv returns $GITEA_TOKEN.
+func TokenFromEnv() string {
+	return os.Getenv("GITEA_TOKEN")
+}
+
+// Provider is forge.Provider for Gitea and Forgejo (Codeberg).
+// Host is the hostname of the server such as "codeberg.org".
+type Provider struct {
+	Host string
+	// Token is sent with every request when it is not empty.
+	Token string
+	// Client sends the requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+type asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadUrl string `json:"browser_download_url"`
+}
+
+type release struct {
+	TagName    string   `json:"tag_name"`
+	Draft      bool     `json:"draft"`
+	Prerelease bool     `json:"prerelease"`
+	Assets     []*asset `json:"assets"`
+}
+
+type serverError struct {
+	Message string `json:"message"`
+	Url     string `json:"url"`
+}
+
+func (r *release) toForge() *forge.Release {
+	result := &forge.Release{
+		TagName:    r.TagName,
+		Draft:      r.Draft,
+		Prerelease: r.Prerelease,
+	}
+	for _, a := range r.Assets {
+		result.Assets = append(result.Assets, &forge.Asset{
+			Name: a.Name,
+			Url:  a.BrowserDownloadUrl,
+		})
+	}
+	return result
+}
+
+func (p *Provider) api(owner, repo string) string {
+	return fmt.Sprintf("https://%s/api/v1/repos/%s/%s", p.Host, owner, repo)
+}
+
+func (p *Provider) get(ctx context.Context, url string, log io.Writer) (*forge.Response, error) {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	if p.Token != "" {
+		header.Set("Authorization", "token "+p.Token)
+	}
+	resp, err := forge.Get(ctx, p.Client, url, header, log)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		var se serverError
+		if json.Unmarshal(resp.Body, &se) == nil && se.Message != "" {
+			if se.Url == "" {
+				return nil, fmt.Errorf("ServerError: %s", se.Message)
+			}
+			return nil, fmt.Errorf("ServerError: %s\n%s", se.Message, se.Url)
+		}
+		return nil, fmt.Errorf("%s: %d %s", url, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+func parseReleases(bin []byte) ([]*forge.Release, error) {
+	var releases []*release
+	if err := json.Unmarshal(bin, &releases); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w\n%s", err, bin)
+	}
+	result := make([]*forge.Release, 0, len(releases))
+	for _, r := range releases {
+		result = append(result, r.toForge())
+	}
+	return result, nil
+}
+
+func (p *Provider) Name() string {
+	return "Gitea"
+}
+
+func (p *Provider) ListReleases(ctx context.Context, owner, repo string, log io.Writer) ([]*forge.Release, error) {
+	var releases []*forge.Release
+	_, err := forge.FindInPages(p.api(owner, repo)+"/releases?limit=50",
+		func(url string) (*forge.Response, error) { return p.get(ctx, url, log) },
+		parseReleases,
+		func(r *forge.Release) bool {
+			releases = append(releases, r)
+			return false
+		})
+	if err != nil && err != forge.ErrNoReleases {
+		return nil, err
+	}
+	return releases, nil
+}
+
+func (p *Provider) FindRelease(ctx context.Context, owner, repo string, log io.Writer, match func(*forge.Release) bool) (*forge.Release, error) {
+	r, err := forge.FindInPages(p.api(owner, repo)+"/releases",
+		func(url string) (*forge.Response, error) { return p.get(ctx, url, log) },
+		parseReleases,
+		match)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", owner, repo, err)
+	}
+	return r, nil
+}
+
+func (p *Provider) getRelease(ctx context.Context, url string, log io.Writer) (*forge.Release, error) {
+	resp, err := p.get(ctx, url, log)
+	if err != nil {
+		return nil, err
+	}
+	var r release
+	if err := json.Unmarshal(resp.Body, &r); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w\n%s", err, resp.Body)
+	}
+	return r.toForge(), nil
+}
+
+func (p *Provider) GetLatestRelease(ctx context.Context, owner, repo string, log io.Writer) (*forge.Release, error) {
+	return p.getRelease(ctx, p.api(owner, repo)+"/releases/latest", log)
+}
+
+func (p *Provider) GetReleaseByTag(ctx context.Context, owner, repo, tag string, log io.Writer) (*forge.Release, error) {
+	r, err := p.getRelease(ctx, p.api(owner, repo)+"/releases/tags/"+url.PathEscape(tag), log)
+	if err != nil && !strings.HasPrefix(tag, "v") {
+		if r1, err1 := p.getRelease(ctx, p.api(owner, repo)+"/releases/tags/v"+url.PathEscape(tag), log); err1 == nil {
+			return r1, nil
+		}
+	}
+	return r, err
+}
+
+type repository struct {
+	Description string   `json:"description"`
+	Licenses    []string `json:"licenses"`
+}
+
+// GetDescription returns the first of the SPDX identifiers of the
+// licenses Gitea detected. Gitea tells neither the license file nor its
+// URL, so the license Gitea does not know is detected only from the one
+// in the archive.
+func (p *Provider) GetDescription(ctx context.Context, owner, repo string, log io.Writer) (*forge.Description, error) {
+	resp, err := p.get(ctx, p.api(owner, repo), log)
+	if err != nil {
+		return nil, err
+	}
+	var r repository
+	if err := json.Unmarshal(resp.Body, &r); err != nil {
+		return nil, err
+	}
+	desc := &forge.Description{Description: r.Description}
+	if len(r.Licenses) > 0 {
+		desc.License = r.Licenses[0]
+	}
+	return desc, nil
+}
+
+func (p *Provider) Homepage(owner, repo string) string {
+	return fmt.Sprintf("https://%s/%s/%s", p.Host, owner, repo)
+}
+
+func (p *Provider) ReleasesApi(owner, repo string) string {
+	return p.api(owner, repo) + "/releases"
+}
+
+// CheckVer returns the rule reading the tag of the latest release
+// with the releases API because Scoop does not know Gitea by name.
+func (p *Provider) CheckVer(owner, repo, regex string) any {
+	if regex == "" {
+		regex = `v?([\d.]+)`
+	}
+	return &forge.CheckVer{
+		Url:      p.api(owner, repo) + "/releases/latest",
+		JsonPath: "$.tag_name",
+		Regex:    regex,
+	}
+}
@@ -0,0 +1,138 @@
+package gitea
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// newTestProvider returns Provider for the server of the repository
+// "o/r" with two pages of the releases.
+func newTestProvider(t *testing.T) *Provider {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"token is required","url":"https://example.com/api/swagger"}`))
+			return
+		}
+		const api = "/api/v1/repos/o/r"
+		switch r.URL.Path {
+		case api + "/releases":
+			if r.URL.Query().Get("page") == "2" {
+				w.Write([]byte(`[{"tag_name":"v1.0.0","assets":[{"name":"r-1.0.0-windows-amd64.zip","browser_download_url":"https://example.com/o/r/releases/download/v1.0.0/r-1.0.0-windows-amd64.zip"}]}]`))
+				return
+			}
+			w.Header().Set("Link", `<`+server.URL+api+`/releases?page=2>; rel="next"`)
+			w.Write([]byte(`[{"tag_name":"v3.0.0","draft":true,"assets":[]},{"tag_name":"v2.0.0-rc1","prerelease":true,"assets":[]}]`))
+		case api + "/releases/latest":
+			w.Write([]byte(`{"tag_name":"v1.0.0","assets":[]}`))
+		case api + "/releases/tags/v1.0.0":
+			w.Write([]byte(`{"tag_name":"v1.0.0","assets":[{"name":"r-1.0.0-windows-amd64.zip","browser_download_url":"https://example.com/o/r/releases/download/v1.0.0/r-1.0.0-windows-amd64.zip"}]}`))
+		case api:
+			w.Write([]byte(`{"description":"app","licenses":["MIT","Apache-2.0"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"The target couldn't be found.","url":"https://example.com/api/swagger"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return &Provider{
+		Host:   strings.TrimPrefix(server.URL, "https://"),
+		Token:  "secret",
+		Client: server.Client(),
+	}
+}
+
+func TestReleases(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+
+	releases, err := p.ListReleases(ctx, "o", "r", io.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var tags []string
+	for _, r := range releases {
+		tags = append(tags, r.TagName)
+	}
+	if strings.Join(tags, " ") != "v3.0.0 v2.0.0-rc1 v1.0.0" || !releases[0].Draft || !releases[1].Prerelease {
+		t.Fatalf("expect the releases of the two pages, but %v", tags)
+	}
+
+	found, err := p.FindRelease(ctx, "o", "r", io.Discard, func(r *forge.Release) bool {
+		return !r.Draft && !r.Prerelease
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if found.TagName != "v1.0.0" || len(found.Assets) != 1 {
+		t.Fatalf("expect v1.0.0 on the second page, but %+v", found)
+	}
+
+	latest, err := p.GetLatestRelease(ctx, "o", "r", io.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if latest.TagName != "v1.0.0" {
+		t.Fatalf("expect the latest v1.0.0, but %s", latest.TagName)
+	}
+}
+
+func TestGetReleaseByTag(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+
+	// The tag without "v" is found as the tag with "v"
+	for _, tag := range []string{"v1.0.0", "1.0.0"} {
+		r, err := p.GetReleaseByTag(ctx, "o", "r", tag, io.Discard)
+		if err != nil {
+			t.Fatalf("%s: %s", tag, err.Error())
+		}
+		if r.TagName != "v1.0.0" || r.Assets[0].Name != "r-1.0.0-windows-amd64.zip" {
+			t.Fatalf("%s: unexpected release %+v", tag, r)
+		}
+	}
+	_, err := p.GetReleaseByTag(ctx, "o", "r", "v9.9.9", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "The target couldn't be found.") {
+		t.Fatalf("expect the message of the server, but %v", err)
+	}
+}
+
+func TestGetDescription(t *testing.T) {
+	p := newTestProvider(t)
+	desc, err := p.GetDescription(context.Background(), "o", "r", io.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if desc.Description != "app" || desc.License != "MIT" || desc.LicenseUrl != "" {
+		t.Fatalf("unexpected description %+v", desc)
+	}
+
+	p.Token = ""
+	_, err = p.GetDescription(context.Background(), "o", "r", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "token is required") {
+		t.Fatalf("expect the error without the token, but %v", err)
+	}
+}
+
+func TestUrls(t *testing.T) {
+	p := &Provider{Host: "codeberg.org"}
+	if u := p.Homepage("o", "r"); u != "https://codeberg.org/o/r" {
+		t.Fatalf("unexpected homepage %s", u)
+	}
+	if u := p.ReleasesApi("o", "r"); u != "https://codeberg.org/api/v1/repos/o/r/releases" {
+		t.Fatalf("unexpected releases API %s", u)
+	}
+	checkver, ok := p.CheckVer("o", "r", "").(*forge.CheckVer)
+	if !ok || checkver.Url != "https://codeberg.org/api/v1/repos/o/r/releases/latest" ||
+		checkver.JsonPath != "$.tag_name" || checkver.Regex != `v?([\d.]+)` {
+		t.Fatalf("unexpected checkver %+v", checkver)
+	}
+}
@@ -0,0 +1,88 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// TokenFromEnv returns $GITHUB_TOKEN or $GH_TOKEN.
+func TokenFromEnv() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// rateLimitWarning is the number of the remaining requests
+// below which the rate limit is reported.
+const rateLimitWarning = 10
+
+type RateLimitError struct {
+	Limit int
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub API rate limit (%d requests per hour) exceeded until %s; set GITHUB_TOKEN or -token to raise it",
+		e.Limit, e.Reset.Local().Format(time.DateTime))
+}
+
+type rateLimit struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+func parseRateLimit(h http.Header) (*rateLimit, bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return nil, false
+	}
+	limit, _ := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	return &rateLimit{
+		limit:     limit,
+		remaining: remaining,
+		reset:     time.Unix(reset, 0),
+	}, true
+}
+
+// get sends GET request to url with the token and returns the body
+// and the URL of the next page given by the Link header.
+func (p Provider) get(ctx context.Context, url string, log io.Writer) ([]byte, string, error) {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if p.Token != "" {
+		header.Set("Authorization", "Bearer "+p.Token)
+	}
+	resp, err := forge.Get(ctx, p.Client, url, header, log)
+	if err != nil {
+		return nil, "", err
+	}
+	if rl, ok := parseRateLimit(resp.Header); ok {
+		if rl.remaining <= 0 && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) {
+			return nil, "", &RateLimitError{Limit: rl.limit, Reset: rl.reset}
+		}
+		if rl.remaining < rateLimitWarning {
+			fmt.Fprintf(log, "Warning: GitHub API rate limit: %d of %d requests remaining until %s\n",
+				rl.remaining, rl.limit, rl.reset.Local().Format(time.DateTime))
+		}
+	}
+	if resp.StatusCode >= 400 {
+		var se ServerError
+		if json.Unmarshal(resp.Body, &se) == nil && se.Message != "" {
+			return nil, "", &se
+		}
+		return nil, "", fmt.Errorf("%s: %d %s", url, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return resp.Body, resp.Next, nil
+}
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/releases":
+			w.Write([]byte(`[]`))
+		case "/repos/o/missing/releases":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found","documentation_url":"https://docs.github.com/rest"}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html>Bad Gateway</html>`))
+		}
+	}))
+	defer server.Close()
+
+	p := Provider{ApiUrl: server.URL, Client: server.Client()}
+	ctx := context.Background()
+
+	if _, _, err := p.get(ctx, p.api("o", "r")+"/releases", io.Discard); err != nil {
+		t.Fatalf("expect no error, but %s", err.Error())
+	}
+
+	_, _, err := p.get(ctx, p.api("o", "missing")+"/releases", io.Discard)
+	var se *ServerError
+	if !errors.As(err, &se) || se.Message != "Not Found" {
+		t.Fatalf("expect ServerError of Not Found, but %v", err)
+	}
+
+	_, _, err = p.get(ctx, p.api("o", "broken")+"/releases", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "502 Bad Gateway") {
+		t.Fatalf("expect 502 Bad Gateway, but %v", err)
+	}
+}
+
+func TestGetDescriptionWithoutLicenseFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/o/r" {
+			w.Write([]byte(`{"name":"r","description":"app","license":{"spdx_id":"NOASSERTION","name":"Other"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	p := Provider{ApiUrl: server.URL, Client: server.Client()}
+	var log strings.Builder
+	desc, err := p.GetDescription(context.Background(), "o", "r", &log)
+	if err != nil {
+		t.Fatalf("expect no error, but %s", err.Error())
+	}
+	if desc.Description != "app" || desc.License != "Other" || desc.LicenseUrl != "" {
+		t.Fatalf("unexpected description %+v", desc)
+	}
+	if !strings.Contains(log.String(), "Warning: o/r: license file:") {
+		t.Fatalf("expect the warning, but %q", log.String())
+	}
+}
@@ -1,21 +1,15 @@
 package github
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
-	"fmt"
 	"io"
-	"net/http"
 )
 
-func queryDescription(user, repo string, log io.Writer) ([]byte, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", user, repo)
-	fmt.Fprintln(log, "Get:", url)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
+func (p Provider) queryDescription(ctx context.Context, user, repo string, log io.Writer) ([]byte, error) {
+	bin, _, err := p.get(ctx, p.api(user, repo), log)
+	return bin, err
 }
 
 type Description struct {
@@ -24,8 +18,8 @@ type Description struct {
 	License     map[string]string `json:"license"`
 }
 
-func GetDescription(user, repo string, log io.Writer) (*Description, error) {
-	bin, err := queryDescription(user, repo, log)
+func (p Provider) getDescription(ctx context.Context, user, repo string, log io.Writer) (*Description, error) {
+	bin, err := p.queryDescription(ctx, user, repo, log)
 	if err != nil {
 		return nil, err
 	}
@@ -35,3 +29,31 @@ func GetDescription(user, repo string, log io.Writer) (*Description, error) {
 	}
 	return desc, nil
 }
+
+// licenseFile is the response of the API for the license file
+type licenseFile struct {
+	HtmlUrl  string `json:"html_url"`
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+// getLicenseFile returns the URL and the contents of the license file
+// which GitHub detected in the repository. The URL is "" when there is none.
+func (p Provider) getLicenseFile(ctx context.Context, user, repo string, log io.Writer) (string, []byte, error) {
+	bin, _, err := p.get(ctx, p.api(user, repo)+"/license", log)
+	if err != nil {
+		return "", nil, err
+	}
+	var file licenseFile
+	if err := json.Unmarshal(bin, &file); err != nil {
+		return "", nil, err
+	}
+	if file.Encoding != "base64" {
+		return file.HtmlUrl, nil, nil
+	}
+	text, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return "", nil, err
+	}
+	return file.HtmlUrl, text, nil
+}
@@ -0,0 +1,164 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// Provider is forge.Provider for GitHub and GitHub Enterprise Server.
+type Provider struct {
+	// ServerUrl is the base URL of the web pages (default: https://github.com)
+	ServerUrl string
+	// ApiUrl is the base URL of REST API (default: ApiUrlFor(ServerUrl))
+	ApiUrl string
+	// Token is sent as the bearer token with every request when it is not empty.
+	Token string
+	// Client sends the requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+const (
+	DefaultServerUrl = "https://github.com"
+	DefaultApiUrl    = "https://api.github.com"
+)
+
+// ApiUrlFor returns the base URL of REST API for the server:
+// https://api.github.com for GitHub.com and SERVER/api/v3 for
+// GitHub Enterprise Server.
+func ApiUrlFor(serverUrl string) string {
+	serverUrl = strings.TrimSuffix(serverUrl, "/")
+	if serverUrl == "" || serverUrl == DefaultServerUrl {
+		return DefaultApiUrl
+	}
+	return serverUrl + "/api/v3"
+}
+
+func (p Provider) server() string {
+	if p.ServerUrl == "" {
+		return DefaultServerUrl
+	}
+	return strings.TrimSuffix(p.ServerUrl, "/")
+}
+
+func (p Provider) api(owner, repo string) string {
+	apiUrl := strings.TrimSuffix(p.ApiUrl, "/")
+	if apiUrl == "" {
+		apiUrl = ApiUrlFor(p.ServerUrl)
+	}
+	return fmt.Sprintf("%s/repos/%s/%s", apiUrl, owner, repo)
+}
+
+func (r *Release) toForge() *forge.Release {
+	release := &forge.Release{
+		TagName:    r.TagName,
+		Draft:      r.Draft,
+		Prerelease: r.Prerelease,
+	}
+	for _, a := range r.Assets {
+		release.Assets = append(release.Assets, &forge.Asset{
+			Name:   a.Name,
+			Url:    a.BrowserDownloadUrl,
+			Digest: a.Digest,
+		})
+	}
+	return release
+}
+
+func (Provider) Name() string {
+	return "GitHub"
+}
+
+func (p Provider) ListReleases(ctx context.Context, owner, repo string, log io.Writer) ([]*forge.Release, error) {
+	releases, err := p.listReleases(ctx, owner, repo, log)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*forge.Release, 0, len(releases))
+	for _, r := range releases {
+		result = append(result, r.toForge())
+	}
+	return result, nil
+}
+
+func (p Provider) FindRelease(ctx context.Context, owner, repo string, log io.Writer, match func(*forge.Release) bool) (*forge.Release, error) {
+	var found *forge.Release
+	_, err := p.findRelease(ctx, owner, repo, log, func(r *Release) bool {
+		found = r.toForge()
+		return match(found)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (p Provider) GetLatestRelease(ctx context.Context, owner, repo string, log io.Writer) (*forge.Release, error) {
+	r, err := p.getLatestRelease(ctx, owner, repo, log)
+	if err != nil {
+		return nil, err
+	}
+	return r.toForge(), nil
+}
+
+func (p Provider) GetReleaseByTag(ctx context.Context, owner, repo, tag string, log io.Writer) (*forge.Release, error) {
+	r, err := p.getReleaseByTag(ctx, owner, repo, tag, log)
+	if err != nil {
+		return nil, err
+	}
+	return r.toForge(), nil
+}
+
+func (p Provider) GetDescription(ctx context.Context, owner, repo string, log io.Writer) (*forge.Description, error) {
+	desc, err := p.getDescription(ctx, owner, repo, log)
+	if err != nil {
+		return nil, err
+	}
+	result := &forge.Description{
+		Description: desc.Description,
+		License:     desc.License["spdx_id"],
+	}
+	// "NOASSERTION" is given for the licenses GitHub does not know
+	if result.License != "" && result.License != "NOASSERTION" {
+		return result, nil
+	}
+	result.License = desc.License["name"]
+	// The license is not required, so the description is returned
+	// without the license file when it can not be read.
+	licenseUrl, licenseText, err := p.getLicenseFile(ctx, owner, repo, log)
+	if err != nil {
+		fmt.Fprintf(log, "Warning: %s/%s: license file: %s\n", owner, repo, err.Error())
+		return result, nil
+	}
+	result.LicenseUrl, result.LicenseText = licenseUrl, licenseText
+	return result, nil
+}
+
+func (p Provider) Homepage(owner, repo string) string {
+	return fmt.Sprintf("%s/%s/%s", p.server(), owner, repo)
+}
+
+func (p Provider) ReleasesApi(owner, repo string) string {
+	return p.api(owner, repo) + "/releases"
+}
+
+// CheckVer returns "github" for GitHub.com with the default regex.
+// Because Scoop knows only GitHub.com by name, the rule reading
+// the latest release with the API is returned otherwise.
+func (p Provider) CheckVer(owner, repo, regex string) any {
+	if regex == "" {
+		if p.server() == DefaultServerUrl {
+			return "github"
+		}
+		regex = `v?([\d.]+)`
+	}
+	return &forge.CheckVer{
+		Url:      p.api(owner, repo) + "/releases/latest",
+		JsonPath: "$.tag_name",
+		Regex:    regex,
+	}
+}
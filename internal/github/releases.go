@@ -1,26 +1,20 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
-)
+	"net/url"
+	"strings"
 
-func queryReleases(user, repo string, log io.Writer) ([]byte, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", user, repo)
-	fmt.Fprintln(log, "Get:", url)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	return io.ReadAll(resp.Body)
-}
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
 
 type Asset struct {
 	Name               string `json:"name"`
 	BrowserDownloadUrl string `json:"browser_download_url"`
+	Digest             string `json:"digest"`
 }
 
 type Release struct {
@@ -39,14 +33,13 @@ type ServerError struct {
 }
 
 func (e ServerError) Error() string {
+	if e.Url == "" {
+		return fmt.Sprintf("ServerError: %s", e.Message)
+	}
 	return fmt.Sprintf("ServerError: %s\n%s", e.Message, e.Url)
 }
 
-func GetReleases(name, repo string, log io.Writer) ([]*Release, error) {
-	releasesStr, err := queryReleases(name, repo, log)
-	if err != nil {
-		return nil, fmt.Errorf("getReleases: %w", err)
-	}
+func parseReleases(releasesStr []byte) ([]*Release, error) {
 	var releases []*Release
 	if err := json.Unmarshal(releasesStr, &releases); err != nil {
 		var se ServerError
@@ -56,8 +49,84 @@ func GetReleases(name, repo string, log io.Writer) ([]*Release, error) {
 		}
 		return nil, fmt.Errorf("json.Unmarshal: %w\n%s", err, releasesStr)
 	}
-	for len(releases) > 0 && (releases[0].Draft || releases[0].Prerelease) {
-		releases = releases[1:]
+	return releases, nil
+}
+
+// listReleases returns all the releases following the pagination.
+func (p Provider) listReleases(ctx context.Context, name, repo string, log io.Writer) ([]*Release, error) {
+	var releases []*Release
+	url := p.api(name, repo) + "/releases?per_page=100"
+	for url != "" {
+		releasesStr, next, err := p.get(ctx, url, log)
+		if err != nil {
+			return nil, fmt.Errorf("listReleases: %w", err)
+		}
+		page, err := parseReleases(releasesStr)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, page...)
+		url = next
 	}
 	return releases, nil
 }
+
+// findRelease returns the first release for which match returns true
+// without reading the pages after it.
+func (p Provider) findRelease(ctx context.Context, name, repo string, log io.Writer, match func(*Release) bool) (*Release, error) {
+	url := p.api(name, repo) + "/releases"
+	for url != "" {
+		releasesStr, next, err := p.get(ctx, url, log)
+		if err != nil {
+			return nil, fmt.Errorf("findRelease: %w", err)
+		}
+		page, err := parseReleases(releasesStr)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page {
+			if match(r) {
+				return r, nil
+			}
+		}
+		url = next
+	}
+	return nil, fmt.Errorf("%s/%s: %w", name, repo, forge.ErrNoReleases)
+}
+
+func (p Provider) getRelease(ctx context.Context, url string, log io.Writer) (*Release, error) {
+	releaseStr, _, err := p.get(ctx, url, log)
+	if err != nil {
+		return nil, err
+	}
+	var release Release
+	if err := json.Unmarshal(releaseStr, &release); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w\n%s", err, releaseStr)
+	}
+	if release.TagName == "" {
+		var se ServerError
+		if json.Unmarshal(releaseStr, &se) == nil && se.Message != "" {
+			return nil, &se
+		}
+		return nil, fmt.Errorf("%s: %w", url, forge.ErrNoReleases)
+	}
+	return &release, nil
+}
+
+// getLatestRelease returns the release GitHub marks as the latest.
+func (p Provider) getLatestRelease(ctx context.Context, name, repo string, log io.Writer) (*Release, error) {
+	return p.getRelease(ctx, p.api(name, repo)+"/releases/latest", log)
+}
+
+// getReleaseByTag returns the release for the tag.
+// When it is not found and the tag does not start with "v",
+// the tag with "v" is tried too.
+func (p Provider) getReleaseByTag(ctx context.Context, name, repo, tag string, log io.Writer) (*Release, error) {
+	release, err := p.getRelease(ctx, p.api(name, repo)+"/releases/tags/"+url.PathEscape(tag), log)
+	if err != nil && !strings.HasPrefix(tag, "v") {
+		if release1, err1 := p.getRelease(ctx, p.api(name, repo)+"/releases/tags/v"+url.PathEscape(tag), log); err1 == nil {
+			return release1, nil
+		}
+	}
+	return release, err
+}
@@ -0,0 +1,214 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// TokenFromEnv returns $GITLAB_TOKEN.
+func TokenFromEnv() string {
+	return os.Getenv("GITLAB_TOKEN")
+}
+
+// Provider is forge.Provider for GitLab.
+// Host is the hostname of the server such as "gitlab.com".
+type Provider struct {
+	Host string
+	// Token is sent with every request when it is not empty.
+	Token string
+	// Client sends the requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+type link struct {
+	Name           string `json:"name"`
+	Url            string `json:"url"`
+	DirectAssetUrl string `json:"direct_asset_url"`
+}
+
+type release struct {
+	TagName         string `json:"tag_name"`
+	UpcomingRelease bool   `json:"upcoming_release"`
+	Assets          struct {
+		Links []*link `json:"links"`
+	} `json:"assets"`
+}
+
+type serverError struct {
+	Message any    `json:"message"`
+	Err     string `json:"error"`
+}
+
+func (r *release) toForge() *forge.Release {
+	result := &forge.Release{
+		TagName:    r.TagName,
+		Prerelease: r.UpcomingRelease,
+	}
+	for _, l := range r.Assets.Links {
+		u := l.DirectAssetUrl
+		if u == "" {
+			u = l.Url
+		}
+		result.Assets = append(result.Assets, &forge.Asset{
+			Name: l.Name,
+			Url:  u,
+		})
+	}
+	return result
+}
+
+func (p *Provider) api(owner, repo string) string {
+	return fmt.Sprintf("https://%s/api/v4/projects/%s",
+		p.Host, url.PathEscape(owner+"/"+repo))
+}
+
+func (p *Provider) get(ctx context.Context, url string, log io.Writer) (*forge.Response, error) {
+	header := http.Header{}
+	if p.Token != "" {
+		header.Set("PRIVATE-TOKEN", p.Token)
+	}
+	resp, err := forge.Get(ctx, p.Client, url, header, log)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		var se serverError
+		if json.Unmarshal(resp.Body, &se) == nil {
+			if se.Message != nil {
+				return nil, fmt.Errorf("ServerError: %v", se.Message)
+			}
+			if se.Err != "" {
+				return nil, fmt.Errorf("ServerError: %s", se.Err)
+			}
+		}
+		return nil, fmt.Errorf("%s: %d %s", url, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+func parseReleases(bin []byte) ([]*forge.Release, error) {
+	var releases []*release
+	if err := json.Unmarshal(bin, &releases); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w\n%s", err, bin)
+	}
+	result := make([]*forge.Release, 0, len(releases))
+	for _, r := range releases {
+		result = append(result, r.toForge())
+	}
+	return result, nil
+}
+
+func (p *Provider) Name() string {
+	return "GitLab"
+}
+
+func (p *Provider) ListReleases(ctx context.Context, owner, repo string, log io.Writer) ([]*forge.Release, error) {
+	var releases []*forge.Release
+	_, err := forge.FindInPages(p.api(owner, repo)+"/releases?per_page=100",
+		func(url string) (*forge.Response, error) { return p.get(ctx, url, log) },
+		parseReleases,
+		func(r *forge.Release) bool {
+			releases = append(releases, r)
+			return false
+		})
+	if err != nil && err != forge.ErrNoReleases {
+		return nil, err
+	}
+	return releases, nil
+}
+
+func (p *Provider) FindRelease(ctx context.Context, owner, repo string, log io.Writer, match func(*forge.Release) bool) (*forge.Release, error) {
+	r, err := forge.FindInPages(p.api(owner, repo)+"/releases",
+		func(url string) (*forge.Response, error) { return p.get(ctx, url, log) },
+		parseReleases,
+		match)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", owner, repo, err)
+	}
+	return r, nil
+}
+
+func (p *Provider) getRelease(ctx context.Context, url string, log io.Writer) (*forge.Release, error) {
+	resp, err := p.get(ctx, url, log)
+	if err != nil {
+		return nil, err
+	}
+	var r release
+	if err := json.Unmarshal(resp.Body, &r); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w\n%s", err, resp.Body)
+	}
+	return r.toForge(), nil
+}
+
+func (p *Provider) GetLatestRelease(ctx context.Context, owner, repo string, log io.Writer) (*forge.Release, error) {
+	return p.getRelease(ctx, p.api(owner, repo)+"/releases/permalink/latest", log)
+}
+
+func (p *Provider) GetReleaseByTag(ctx context.Context, owner, repo, tag string, log io.Writer) (*forge.Release, error) {
+	r, err := p.getRelease(ctx, p.api(owner, repo)+"/releases/"+url.PathEscape(tag), log)
+	if err != nil && !strings.HasPrefix(tag, "v") {
+		if r1, err1 := p.getRelease(ctx, p.api(owner, repo)+"/releases/v"+url.PathEscape(tag), log); err1 == nil {
+			return r1, nil
+		}
+	}
+	return r, err
+}
+
+type project struct {
+	Description string `json:"description"`
+	License     *struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	} `json:"license"`
+	// LicenseUrl is the license file in the repository
+	LicenseUrl string `json:"license_url"`
+}
+
+// GetDescription returns the name of the license GitLab detected and
+// the URL of the license file. The license file itself is not read,
+// so the license GitLab does not know is detected only from the one
+// in the archive.
+func (p *Provider) GetDescription(ctx context.Context, owner, repo string, log io.Writer) (*forge.Description, error) {
+	resp, err := p.get(ctx, p.api(owner, repo)+"?license=true", log)
+	if err != nil {
+		return nil, err
+	}
+	var prj project
+	if err := json.Unmarshal(resp.Body, &prj); err != nil {
+		return nil, err
+	}
+	desc := &forge.Description{Description: prj.Description, LicenseUrl: prj.LicenseUrl}
+	if prj.License != nil {
+		desc.License = prj.License.Name
+	}
+	return desc, nil
+}
+
+func (p *Provider) Homepage(owner, repo string) string {
+	return fmt.Sprintf("https://%s/%s/%s", p.Host, owner, repo)
+}
+
+func (p *Provider) ReleasesApi(owner, repo string) string {
+	return p.api(owner, repo) + "/releases"
+}
+
+// CheckVer returns the rule reading the tag of the newest release
+// with the releases API because Scoop does not know GitLab by name.
+func (p *Provider) CheckVer(owner, repo, regex string) any {
+	if regex == "" {
+		regex = `v?([\d.]+)`
+	}
+	return &forge.CheckVer{
+		Url:      p.api(owner, repo) + "/releases",
+		JsonPath: "$[0].tag_name",
+		Regex:    regex,
+	}
+}
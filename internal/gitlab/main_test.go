@@ -0,0 +1,151 @@
+package gitlab
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// newTestProvider returns Provider for the server of the nested group
+// project "g/s/r" with two pages of the releases.
+func newTestProvider(t *testing.T) *Provider {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"401 Unauthorized"}`))
+			return
+		}
+		const api = "/api/v4/projects/g%2Fs%2Fr"
+		switch r.URL.EscapedPath() {
+		case api + "/releases":
+			if r.URL.Query().Get("page") == "2" {
+				w.Write([]byte(`[{"tag_name":"v1.0.0","assets":{"links":[{"name":"r-1.0.0-windows-amd64.zip","url":"https://example.com/r-1.0.0.zip"}]}}]`))
+				return
+			}
+			w.Header().Set("Link", `<`+server.URL+api+`/releases?page=2>; rel="next"`)
+			w.Write([]byte(`[{"tag_name":"v3.0.0-rc1","upcoming_release":true,"assets":{"links":[]}},{"tag_name":"v2.0.0","assets":{"links":[]}}]`))
+		case api + "/releases/permalink/latest":
+			w.Write([]byte(`{"tag_name":"v2.0.0","assets":{"links":[]}}`))
+		case api + "/releases/v1.0.0":
+			w.Write([]byte(`{"tag_name":"v1.0.0","assets":{"links":[{"name":"r-1.0.0-windows-amd64.zip","url":"https://example.com/r-1.0.0.zip","direct_asset_url":"https://example.com/g/s/r/-/releases/v1.0.0/downloads/r-1.0.0-windows-amd64.zip"}]}}`))
+		case api:
+			if r.URL.Query().Get("license") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"description":"app","license":{"key":"mit","name":"MIT License"},"license_url":"https://example.com/g/s/r/-/blob/main/LICENSE"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"404 Not Found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return &Provider{
+		Host:   strings.TrimPrefix(server.URL, "https://"),
+		Token:  "secret",
+		Client: server.Client(),
+	}
+}
+
+func TestReleases(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+
+	releases, err := p.ListReleases(ctx, "g/s", "r", io.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var tags []string
+	for _, r := range releases {
+		tags = append(tags, r.TagName)
+	}
+	if strings.Join(tags, " ") != "v3.0.0-rc1 v2.0.0 v1.0.0" || !releases[0].Prerelease {
+		t.Fatalf("expect the releases of the two pages, but %v", tags)
+	}
+
+	found, err := p.FindRelease(ctx, "g/s", "r", io.Discard, func(r *forge.Release) bool {
+		return len(r.Assets) > 0
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if found.TagName != "v1.0.0" || found.Assets[0].Url != "https://example.com/r-1.0.0.zip" {
+		t.Fatalf("expect v1.0.0 on the second page, but %+v", found)
+	}
+
+	latest, err := p.GetLatestRelease(ctx, "g/s", "r", io.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if latest.TagName != "v2.0.0" {
+		t.Fatalf("expect the latest v2.0.0, but %s", latest.TagName)
+	}
+}
+
+func TestGetReleaseByTag(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+
+	// The tag without "v" is found as the tag with "v"
+	for _, tag := range []string{"v1.0.0", "1.0.0"} {
+		r, err := p.GetReleaseByTag(ctx, "g/s", "r", tag, io.Discard)
+		if err != nil {
+			t.Fatalf("%s: %s", tag, err.Error())
+		}
+		if r.TagName != "v1.0.0" {
+			t.Fatalf("%s: expect v1.0.0, but %s", tag, r.TagName)
+		}
+		// direct_asset_url precedes url
+		if expect := "https://example.com/g/s/r/-/releases/v1.0.0/downloads/r-1.0.0-windows-amd64.zip"; r.Assets[0].Url != expect {
+			t.Fatalf("%s: expect %s, but %s", tag, expect, r.Assets[0].Url)
+		}
+	}
+	_, err := p.GetReleaseByTag(ctx, "g/s", "r", "v9.9.9", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Fatalf("expect 404 Not Found, but %v", err)
+	}
+}
+
+func TestGetDescription(t *testing.T) {
+	p := newTestProvider(t)
+	desc, err := p.GetDescription(context.Background(), "g/s", "r", io.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if desc.Description != "app" || desc.License != "MIT License" ||
+		desc.LicenseUrl != "https://example.com/g/s/r/-/blob/main/LICENSE" {
+		t.Fatalf("unexpected description %+v", desc)
+	}
+
+	p.Token = ""
+	_, err = p.GetDescription(context.Background(), "g/s", "r", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Fatalf("expect 401 Unauthorized without the token, but %v", err)
+	}
+}
+
+func TestUrls(t *testing.T) {
+	p := &Provider{Host: "gitlab.example.com"}
+	if u := p.Homepage("g/s", "r"); u != "https://gitlab.example.com/g/s/r" {
+		t.Fatalf("unexpected homepage %s", u)
+	}
+	const api = "https://gitlab.example.com/api/v4/projects/g%2Fs%2Fr/releases"
+	if u := p.ReleasesApi("g/s", "r"); u != api {
+		t.Fatalf("expect %s, but %s", api, u)
+	}
+	checkver, ok := p.CheckVer("g/s", "r", "").(*forge.CheckVer)
+	if !ok || checkver.Url != api || checkver.JsonPath != "$[0].tag_name" || checkver.Regex != `v?([\d.]+)` {
+		t.Fatalf("unexpected checkver %+v", checkver)
+	}
+	checkver = p.CheckVer("g/s", "r", `^app/v([\d.]+)$`).(*forge.CheckVer)
+	if checkver.Regex != `^app/v([\d.]+)$` {
+		t.Fatalf("expect the regex given, but %s", checkver.Regex)
+	}
+}
@@ -0,0 +1,200 @@
+package ordered
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Object is a JSON object which remembers the order of its keys,
+// so that a manifest can be rewritten without shuffling the fields
+// placed by hand.
+type Object struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func (o *Object) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return errors.New("not a JSON object")
+	}
+	o.keys = nil
+	o.values = make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token %v", tok)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if _, ok := o.values[key]; !ok {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = value
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// Marshal is json.Marshal without escaping <, > and & so that
+// the regular expressions like (?<version>...) are kept readable.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+// MarshalIndent is json.MarshalIndent without escaping <, > and &.
+func MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Keys returns the keys in the order they appeared.
+func (o *Object) Keys() []string {
+	return o.keys
+}
+
+// Get returns the raw value for the key.
+func (o *Object) Get(key string) (json.RawMessage, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// Has reports whether the key exists.
+func (o *Object) Has(key string) bool {
+	_, ok := o.values[key]
+	return ok
+}
+
+// Set replaces the value for the key keeping its position,
+// or appends the key when it does not exist yet.
+func (o *Object) Set(key string, value any) error {
+	bin, err := Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if o.values == nil {
+		o.values = make(map[string]json.RawMessage)
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = bin
+	return nil
+}
+
+// Delete removes the key.
+func (o *Object) Delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Insert sets the value for the key like Set, but a new key is placed
+// after the last existing key which precedes it in order instead of the end.
+func (o *Object) Insert(key string, value any, order []string) error {
+	if o.Has(key) {
+		return o.Set(key, value)
+	}
+	if err := o.Set(key, value); err != nil {
+		return err
+	}
+	rank := slices.Index(order, key)
+	if rank < 0 {
+		return nil
+	}
+	pos := 0
+	for i, k := range o.keys[:len(o.keys)-1] {
+		if r := slices.Index(order, k); r >= 0 && r < rank {
+			pos = i + 1
+		}
+	}
+	o.keys = slices.Insert(o.keys[:len(o.keys)-1], pos, key)
+	return nil
+}
+
+func isObject(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '{'
+}
+
+// Merge copies the fields of src into o. When both sides are objects,
+// they are merged recursively so that the fields only o has are kept.
+func (o *Object) Merge(src *Object, order []string) error {
+	for _, key := range src.keys {
+		value := src.values[key]
+		if current, ok := o.values[key]; ok && isObject(current) && isObject(value) {
+			var dst, child Object
+			if err := json.Unmarshal(current, &dst); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if err := json.Unmarshal(value, &child); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if err := dst.Merge(&child, order); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if err := o.Set(key, &dst); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := o.Insert(key, value, order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
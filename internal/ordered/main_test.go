@@ -0,0 +1,56 @@
+package ordered
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	source := `{"version":"1.0","notes":["a","b"],"##":"comment","bin":"foo.exe"}`
+
+	var obj Object
+	if err := json.Unmarshal([]byte(source), &obj); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := obj.Set("version", "2.0"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := obj.Set("hash", "abc"); err != nil {
+		t.Fatal(err.Error())
+	}
+	obj.Delete("notes")
+
+	result, err := json.Marshal(&obj)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := `{"version":"2.0","##":"comment","bin":"foo.exe","hash":"abc"}`
+	if string(result) != expect {
+		t.Fatalf("expect %s, but %s", expect, result)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	template := `{"##":"comment","version":"","notes":"hello","architecture":{"64bit":{"bin":"a.exe"}},"persist":"data"}`
+	generated := `{"version":"1.0","homepage":"https://example.com","architecture":{"64bit":{"url":"U","hash":"H"}},"bin":"b.exe"}`
+	order := []string{"##", "version", "homepage", "notes", "architecture", "url", "hash", "bin", "persist"}
+
+	var doc, gen Object
+	if err := json.Unmarshal([]byte(template), &doc); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := json.Unmarshal([]byte(generated), &gen); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := doc.Merge(&gen, order); err != nil {
+		t.Fatal(err.Error())
+	}
+	result, err := json.Marshal(&doc)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := `{"##":"comment","version":"1.0","homepage":"https://example.com","notes":"hello","architecture":{"64bit":{"url":"U","hash":"H","bin":"a.exe"}},"bin":"b.exe","persist":"data"}`
+	if string(result) != expect {
+		t.Fatalf("expect %s, but %s", expect, result)
+	}
+}
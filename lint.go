@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/hymkor/make-scoop-manifest/scoop"
+)
+
+// exitError is the error which tells the exit code of the process
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// lintCommand runs "lint FILE...", which checks the manifests with
+// scoop.LintRules and prints the problems. With -fix, the problems which
+// can be fixed are fixed in the files. The exit code is 0 when only
+// warnings are left, 1 when errors are left, and 2 when a file can not
+// be checked.
+func lintCommand(args []string, stdout io.Writer, client *http.Client) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: %s lint [-fix] FILE...", os.Args[0])
+	}
+	ctx := context.Background()
+	g := scoop.New(newOptions(nil, nil, client))
+	var errs, warnings, fixed, failed int
+	for _, fname := range args {
+		source, err := os.ReadFile(fname)
+		if err != nil {
+			fmt.Fprintln(stdout, err.Error())
+			failed++
+			continue
+		}
+		var manifest scoop.Manifest
+		if err := json.Unmarshal(source, &manifest); err != nil {
+			fmt.Fprintf(stdout, "%s: %s\n", fname, err.Error())
+			failed++
+			continue
+		}
+		problems, err := g.Lint(ctx, &manifest, *flagFix)
+		if err != nil {
+			fmt.Fprintf(stdout, "%s: %s\n", fname, err.Error())
+			failed++
+			continue
+		}
+		var fixedInFile bool
+		for _, p := range problems {
+			fmt.Fprintf(stdout, "%s: %s\n", fname, p.String())
+			switch {
+			case p.Fixed:
+				fixed++
+				fixedInFile = true
+			case p.Rule.Severity == scoop.SeverityError:
+				errs++
+			default:
+				warnings++
+			}
+		}
+		if !fixedInFile {
+			continue
+		}
+		jsonBin, err := manifest.Format()
+		if err != nil {
+			return err
+		}
+		warnInvalid(fname, jsonBin)
+		if err := os.WriteFile(fname, jsonBin, 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(stdout, "%d errors, %d warnings, %d fixed\n", errs, warnings, fixed)
+	switch {
+	case failed > 0:
+		return &exitError{code: 2, err: fmt.Errorf("%d of %d files can not be checked", failed, len(args))}
+	case errs > 0:
+		return &exitError{code: 1, err: fmt.Errorf("%d errors are found", errs)}
+	}
+	return nil
+}
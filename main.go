@@ -1,9 +1,7 @@
 package main
 
 import (
-	"archive/zip"
-	"bytes"
-	"crypto/sha256"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,15 +9,15 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"slices"
-	"sort"
 	"strings"
 
+	"github.com/hymkor/make-scoop-manifest/internal/archive"
+	"github.com/hymkor/make-scoop-manifest/internal/cache"
 	"github.com/hymkor/make-scoop-manifest/internal/flag"
 	"github.com/hymkor/make-scoop-manifest/internal/gitdir"
-	"github.com/hymkor/make-scoop-manifest/internal/github"
+	"github.com/hymkor/make-scoop-manifest/scoop"
 )
 
 var (
@@ -27,14 +25,32 @@ var (
 	flagStdinTemplate  = flag.Bool("stdin", false, "Read the template of the manifest JSON from the standard input")
 	flagAnyCPU         = flag.Bool("anycpu", false, "Do not use \"architecture\" of the manifest")
 	flagExtractDir     = flag.Bool("p", false, "Specify the parent directory of *.exe into \"extract_dir\" and the basename into \"bin\"")
-	flag32             = flag.String("32", "386,486,586,686,32bit,win32", "When anyone of the specified strings is found in the zipfile's name, judge its architecture is 32bit")
+	flag32             = flag.String("32", "386,486,586,686,32bit,win32,ia32,x86", "When anyone of the specified strings is found in the zipfile's name, judge its architecture is 32bit")
 	flag64             = flag.String("64", "amd64,64bit,win64,x86_64,x64", "When anyone of the specified strings is found in the zipfile's name, judge its architecture is 64bit")
+	flagArm64          = flag.String("arm64", "arm64,aarch64", "When anyone of the specified strings is found in the zipfile's name, judge its architecture is arm64")
+	flagPECheck        = flag.Bool("pecheck", false, "Confirm the architecture with the headers of the executables in the archives")
+	flagStrict         = flag.Bool("strict", false, "With -pecheck, fail when the executables are not for the architecture of the asset name")
 	flagLicense        = flag.String("license", "", "Set the value of \"license\" of the manifest")
 	flagDescription    = flag.String("description", "", "Set the value of \"description\" of the manifest")
 	flagDownloadTo     = flag.String("downloadto", "", "Do not remove the downloaded zip files and save them onto the specified directory")
 	flagBinPattern     = flag.String("binpattern", "*.exe", "The pattern for executables(separated with comma)")
 	flagIgnoreWords    = flag.String("ignore", "linux,macos,freebsd,netbsd,darwin,plan9", "ignore the zipfile whose name contains these words")
 	flagNoAutoUpdate   = flag.Bool("noautoupdate", false, "disable autoupdate")
+	flagToken          = flag.String("token", "", "The token for the API (default: $GITHUB_TOKEN or $GH_TOKEN, $GITLAB_TOKEN, $GITEA_TOKEN)")
+	flagGithubServer   = flag.String("githubserver", "", "The URL of GitHub Enterprise Server (default: $GITHUB_SERVER_URL or https://github.com)")
+	flagGithubApi      = flag.String("githubapi", "", "The URL of REST API of GitHub Enterprise Server (default: $GITHUB_API_URL or SERVER/api/v3)")
+	flagForge          = flag.String("forge", "", "The kind of the forge: github, gitlab or gitea (default: guessed from the hostname of REPOSITORY)")
+	flagTag            = flag.String("tag", "", "Use the release of the specified tag instead of the latest one")
+	flagTagRegex       = flag.String("tagregex", "", "The regular expression with the group (?<version>...) to get the version from the tag, or a preset: v, number, slash, at")
+	flagLatest         = flag.Bool("latest", false, "Use the release GitHub marks as \"Latest\"")
+	flagPrerelease     = flag.Bool("prerelease", false, "Use the newest release including pre-releases")
+	flagVersionsDir    = flag.String("versions", "", "Write the manifests of all the releases as DIR/APP@VERSION.json for the versions bucket")
+	flagLast           = flag.Int("last", 0, "With -versions, only the newest N releases are written")
+	flagJobs           = flag.Int("jobs", 4, "The number of the assets downloaded at the same time")
+	flagNoCache        = flag.Bool("nocache", false, "Download all the assets without the cache of the hashes")
+	flagUpdate         = flag.String("update", "", "Update \"version\", \"url\" and \"hash\" of the existing manifest FILE in place and keep the other fields")
+	flagFix            = flag.Bool("fix", false, "With lint, fix the problems which can be fixed automatically")
+	flagNoConfig       = flag.Bool("noconfig", false, "Do not read "+configName+" in the top directory of the repository")
 )
 
 var (
@@ -44,270 +60,112 @@ var (
 	flagUserAndRepo = flag.String("g", "", "(deprecated) Specify GitHub's \"USER/REPOSITORY\"")
 )
 
-func readTemplate() (*Manifest, error) {
-	var manifest Manifest
-	var input []byte
-
+// readTemplate returns the source of the template given with -update, -inline or -stdin,
+// or the one of the configuration file. It returns nil when no template is given.
+func readTemplate() ([]byte, error) {
+	if *flagUpdate != "" {
+		return os.ReadFile(*flagUpdate)
+	}
 	if *flagInlineTemplate != "" {
-		input = []byte(*flagInlineTemplate)
-	} else if *flagStdinTemplate {
-		var err error
-		input, err = io.ReadAll(os.Stdin)
-		if err != nil && err != io.EOF {
-			return nil, err
-		}
+		return []byte(*flagInlineTemplate), nil
 	}
-	if input != nil {
-		if err := json.Unmarshal(input, &manifest); err != nil {
+	if *flagStdinTemplate {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil && err != io.EOF {
 			return nil, err
 		}
+		return input, nil
 	}
-	return &manifest, nil
+	return configTemplate, nil
 }
 
-// keysToSlice returns keys of map1 as the type []string or a string
-func keysToSlice(map1 map[string]struct{}) any {
-	if map1 == nil {
-		return nil
-	}
-	if len(map1) == 1 {
-		for binname := range map1 {
-			return binname
-		}
+// newOptions returns the options of the generator given with the command line.
+// The requests are sent with client (nil for http.DefaultClient).
+func newOptions(template []byte, localfiles map[string]string, client *http.Client) *scoop.Options {
+	var cacheDir string
+	if !*flagNoCache {
+		cacheDir, _ = cache.DefaultDir()
+	}
+	return &scoop.Options{
+		Template:      template,
+		AnyCPU:        *flagAnyCPU,
+		ExtractDir:    *flagExtractDir,
+		Keywords32:    *flag32,
+		Keywords64:    *flag64,
+		KeywordsArm64: *flagArm64,
+		PECheck:       *flagPECheck,
+		Strict:        *flagStrict,
+		License:       *flagLicense,
+		Description:   *flagDescription,
+		DownloadTo:    *flagDownloadTo,
+		BinPattern:    *flagBinPattern,
+		IgnoreWords:   *flagIgnoreWords,
+		NoAutoUpdate:  *flagNoAutoUpdate,
+		Tag:           *flagTag,
+		TagRegex:      *flagTagRegex,
+		Latest:        *flagLatest,
+		Prerelease:    *flagPrerelease,
+		Token:         *flagToken,
+		Forge:         *flagForge,
+		GithubServer:  *flagGithubServer,
+		GithubApi:     *flagGithubApi,
+		Jobs:          *flagJobs,
+		CacheDir:      cacheDir,
+		LocalFiles:    localfiles,
+		Client:        client,
+		Log:           os.Stderr,
 	}
-	slice := make([]string, 0, len(map1))
-	for key := range map1 {
-		slice = append(slice, key)
-	}
-	sort.Strings(slice)
-	return slice
 }
 
-func getHash(fname string) (string, error) {
-	fd, err := os.Open(fname)
+// updateManifest replaces "version", "url" and "hash" of the manifest
+// file fname whose contents are source with the ones of the release of repo.
+// It returns the version of the release and whether the file is updated.
+func updateManifest(ctx context.Context, g *scoop.Generator, repo, fname string, source []byte, currentVersion string) (string, bool, error) {
+	release, err := g.Release(ctx, repo)
 	if err != nil {
-		return "", err
-	}
-	defer fd.Close()
-
-	h := sha256.New()
-	io.Copy(h, fd)
-	return fmt.Sprintf("%x", h.Sum(nil)), nil
-}
-
-type Archtecture struct {
-	Url        string `json:"url"`
-	Hash       string `json:"hash,omitempty"`
-	ExtractDir string `json:"extract_dir,omitempty"`
-}
-
-type AutoUpdate struct {
-	Archtectures map[string]*Archtecture `json:"architecture,omitempty"`
-	UrlForAnyCPU string                  `json:"url,omitempty"`
-}
-
-type Manifest struct {
-	Version       string                  `json:"version"`
-	Description   string                  `json:"description,omitempty"`
-	Homepage      string                  `json:"homepage,omitempty"`
-	License       string                  `json:"license,omitempty"`
-	Archtectures  map[string]*Archtecture `json:"architecture,omitempty"`
-	UrlForAnyCPU  string                  `json:"url,omitempty"`
-	HashForAnyCPU string                  `json:"hash,omitempty"`
-	Bin           any                     `json:"bin"`
-	CheckVer      any                     `json:"checkver,omitempty"`
-	AutoUpdate    *AutoUpdate             `json:"autoupdate,omitempty"`
-}
-
-func getBits(s string) string {
-	s = strings.ToLower(s)
-	for _, keyword := range strings.Split(*flag64, ",") {
-		if strings.Contains(s, keyword) {
-			return "64bit"
-		}
-	}
-	for _, keyword := range strings.Split(*flag32, ",") {
-		if strings.Contains(s, keyword) {
-			return "32bit"
-		}
+		return "", false, err
 	}
-	if strings.Contains(s, "arm64") {
-		return "arm64"
+	if currentVersion == release.Version() {
+		fmt.Fprintf(os.Stderr, "%s: already up to date (%s)\n", fname, currentVersion)
+		return currentVersion, false, nil
 	}
-	return ""
-}
-
-func listUpExeInZip(fname string, exeFiles map[string]struct{}) (string, error) {
-	zr, err := zip.OpenReader(fname)
+	manifest, err := g.GenerateRelease(ctx, release)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
-	defer zr.Close()
-
-	patterns := strings.Split(strings.ToLower(*flagBinPattern), ",")
-
-	var extractDir string
-	for _, f := range zr.File {
-		lowerName := strings.ToLower(f.Name)
-		for _, pattern := range patterns {
-			if matched, err := filepath.Match(pattern, lowerName); err == nil && matched {
-				nm := f.Name
-				if *flagExtractDir {
-					notDir := filepath.Dir(nm)
-					if notDir != "." {
-						extractDir = notDir
-						nm = filepath.Base(nm)
-					}
-				}
-				exeFiles[nm] = struct{}{}
-				break
-			}
-		}
-	}
-	return extractDir, nil
-}
-
-func writeWithCRLF(source []byte, w io.Writer) error {
-	for {
-		before, after, found := bytes.Cut(source, []byte{'\n'})
-		_, err := w.Write(before)
-		if err != nil {
-			return err
-		}
-		_, err = w.Write([]byte{'\r', '\n'})
-		if err != nil {
-			return err
-		}
-		if !found {
-			return nil
-		}
-		source = after
-	}
-}
-
-func isWindowsZipName(name string) bool {
-	for _, word := range strings.Split(*flagIgnoreWords, ",") {
-		if strings.Contains(name, word) {
-			fmt.Fprintf(os.Stderr, "%s: ignored because it contains %s\n", name, word)
-			return false
-		}
-	}
-	return true
-}
-
-type downloadAsset struct {
-	zipName string
-	hash    string
-	url     string
-}
-
-func (d *downloadAsset) Dispose() {
-	os.Remove(d.zipName)
-}
-
-func downloadAsTmpZip(url, name string) (*downloadAsset, error) {
-	resp, err := http.Get(url)
+	jsonBin, err := scoop.Update(source, manifest, os.Stderr)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", url, err)
-	}
-	defer resp.Body.Close()
-
-	var tmpFd *os.File
-	var tmpZipName string
-	if *flagDownloadTo != "" {
-		tmpZipName = filepath.Join(*flagDownloadTo, name)
-		tmpFd, err = os.Create(tmpZipName)
-		if err != nil {
-			return nil, err
-		}
-		fmt.Fprintln(os.Stderr, "Save as", tmpZipName)
-	} else {
-		tmpFd, err = os.CreateTemp("", "make-scoop-manifest-*.zip")
-		if err != nil {
-			return nil, err
-		}
-		tmpZipName = tmpFd.Name()
+		return "", false, fmt.Errorf("%s: %w", fname, err)
 	}
-
-	io.Copy(tmpFd, resp.Body)
-
-	tmpFd.Seek(0, 0)
-	h := sha256.New()
-	io.Copy(h, tmpFd)
-	hash := fmt.Sprintf("%x", h.Sum(nil))
-	tmpFd.Close()
-
-	return &downloadAsset{
-		zipName: tmpZipName,
-		hash:    hash,
-		url:     url,
-	}, nil
+	warnInvalid(fname, jsonBin)
+	fmt.Fprintf(os.Stderr, "Update %s to %s\n", fname, manifest.Version)
+	return manifest.Version, true, os.WriteFile(fname, jsonBin, 0644)
 }
 
-func readFileAndGetArchitecture(url, fullpath string, foundExecutables map[string]struct{}) (*Archtecture, error) {
-	hash, err := getHash(fullpath)
-	if err != nil {
-		return nil, err
+func mains(args []string, stdout io.Writer, client *http.Client) error {
+	if len(args) > 0 && args[0] == "cache" {
+		return cacheCommand(args[1:])
 	}
-	extractDir, err := listUpExeInZip(fullpath, foundExecutables)
-	if err != nil {
-		return nil, err
+	if len(args) > 0 && args[0] == "bucket" {
+		return bucketCommand(args[1:], stdout, client)
 	}
-	return &Archtecture{
-		Url:        url,
-		Hash:       hash,
-		ExtractDir: extractDir,
-	}, nil
-}
-
-func downloadAndGetArchitecture(url, name string, foundExecutables map[string]struct{}) (*Archtecture, error) {
-	downloadZip, err := downloadAsTmpZip(url, name)
-	if err != nil {
-		return nil, err
+	if len(args) > 0 && args[0] == "validate" {
+		return validateCommand(args[1:], stdout)
 	}
-	defer downloadZip.Dispose()
-
-	extractDir, err := listUpExeInZip(downloadZip.zipName, foundExecutables)
-	if err != nil {
-		return nil, err
+	if len(args) > 0 && args[0] == "lint" {
+		return lintCommand(args[1:], stdout, client)
 	}
-
-	return &Archtecture{
-		Url:        url,
-		Hash:       downloadZip.hash,
-		ExtractDir: extractDir,
-	}, nil
-}
-
-var (
-	rxRepositoryG = regexp.MustCompile(`^git@github.com:([^/]+)/(.+)$`)
-	rxRepositoryH = regexp.MustCompile(`^(?:https://github.com/)?([^/]+)/([^/]+)`)
-)
-
-func mains(args []string) error {
 	// for compatiblity
 	if *flagUserAndRepo != "" {
 		args = slices.Insert(args, 0, *flagUserAndRepo)
 	}
 	localfiles := map[string]string{}
-	var owner, repos string
+	var repo string
 
 	for _, arg1 := range args {
-		if repos == "" && !strings.EqualFold(filepath.Ext(arg1), ".zip") {
-			if m := rxRepositoryG.FindStringSubmatch(arg1); m != nil {
-				owner = m[1]
-				if strings.EqualFold(filepath.Ext(m[2]), ".git") {
-					repos = m[2][:len(m[2])-4]
-				} else {
-					repos = m[2]
-				}
-				continue
-			}
-			if m := rxRepositoryH.FindStringSubmatch(arg1); m != nil {
-				owner = m[1]
-				repos = m[2]
-				continue
-			}
+		if repo == "" && archive.Kind(arg1) == "" && scoop.IsRepository(arg1) {
+			repo = arg1
+			continue
 		}
 		files, err := filepath.Glob(arg1)
 		if err != nil {
@@ -318,135 +176,61 @@ func mains(args []string) error {
 			localfiles[name] = fname
 		}
 	}
-	if owner == "" {
-		var err error
-		owner, repos, err = gitdir.GetNameAndRepo(os.Stderr)
-		if err != nil {
-			return err
-		}
-	}
-	fmt.Fprintln(os.Stderr, "Owner:", owner)
-	fmt.Fprintln(os.Stderr, "Repos:", repos)
-
-	releases, err := github.GetReleases(owner, repos, os.Stderr)
+	source, err := readTemplate()
 	if err != nil {
-		return fmt.Errorf("getReleases: %w", err)
-	}
-	if len(releases) < 1 {
-		return fmt.Errorf("%s/%s: no releases", owner, repos)
+		return err
 	}
-	fmt.Fprintln(os.Stderr, "Search the assets of", releases[0].TagName)
-
-	arch := make(map[string]*Archtecture)
-	var tag string
-
-	var binfiles = map[string]struct{}{}
-
-	for _, asset1 := range releases[0].Assets {
-		name := asset1.Name
-		if !strings.EqualFold(filepath.Ext(name), ".zip") {
-			continue
-		}
-		if !isWindowsZipName(name) {
-			continue
+	var current scoop.Manifest
+	if source != nil {
+		if err := json.Unmarshal(source, &current); err != nil {
+			return err
 		}
-		var bits string
-		if !*flagAnyCPU {
-			bits = getBits(name)
-			if bits == "" {
-				continue
-			}
+	}
+	if *flagUpdate != "" {
+		if len(current.UrlForAnyCPU) > 0 && current.Archtectures == nil {
+			*flagAnyCPU = true
 		}
-		url := asset1.BrowserDownloadUrl
-		if fullpath, ok := localfiles[name]; ok {
-			fmt.Fprintln(os.Stderr, "Read local file:", fullpath)
-			arch[bits], err = readFileAndGetArchitecture(url, fullpath, binfiles)
-		} else {
-			fmt.Fprintln(os.Stderr, "Download:", url)
-			arch[bits], err = downloadAndGetArchitecture(url, name, binfiles)
+		if repo == "" && strings.HasPrefix(current.Homepage, "http") && scoop.IsRepository(current.Homepage) {
+			repo = current.Homepage
 		}
+	}
+	if repo == "" {
+		host, owner, repos, err := gitdir.GetNameAndRepo(os.Stderr)
 		if err != nil {
 			return err
 		}
-		tag = releases[0].TagName
-	}
-
-	manifest, err := readTemplate()
-	if err != nil {
-		return err
+		if owner == "" {
+			return errors.New("the repository is not found with git remote")
+		}
+		repo = fmt.Sprintf("https://%s/%s/%s", host, owner, repos)
 	}
+	ctx := context.Background()
+	options := newOptions(source, localfiles, client)
 
-	if !*flagNoAutoUpdate {
-		manifest.AutoUpdate = &AutoUpdate{}
-	}
-	if *flagDescription != "" {
-		manifest.Description = *flagDescription
-	}
-	if *flagLicense != "" {
-		manifest.License = *flagLicense
+	if *flagVersionsDir != "" {
+		options.NoAutoUpdate = true
+		return makeVersions(ctx, scoop.New(options), repo)
 	}
-	if manifest.Bin == nil {
-		manifest.Bin = keysToSlice(binfiles)
-	}
-	if manifest.Archtectures == nil && !*flagAnyCPU {
-		manifest.Archtectures = make(map[string]*Archtecture)
-	}
-	if manifest.AutoUpdate != nil && manifest.AutoUpdate.Archtectures == nil {
-		manifest.AutoUpdate.Archtectures = map[string]*Archtecture{}
-	}
-	if manifest.Homepage == "" {
-		manifest.Homepage = fmt.Sprintf(
-			"https://github.com/%s/%s", owner, repos)
-	}
-	if !*flagNoAutoUpdate && manifest.CheckVer == nil {
-		manifest.CheckVer = "github"
+	g := scoop.New(options)
+	if *flagUpdate != "" {
+		_, _, err := updateManifest(ctx, g, repo, *flagUpdate, source, current.Version)
+		return err
 	}
-	if *flagAnyCPU {
-		manifest.Version = strings.TrimPrefix(tag, "v")
-		arch1 := arch[""]
-		if arch1 == nil {
-			return errors.New("assets not found")
-		}
-		manifest.UrlForAnyCPU = arch1.Url
-		manifest.HashForAnyCPU = arch1.Hash
-		if manifest.AutoUpdate != nil {
-			manifest.AutoUpdate.UrlForAnyCPU =
-				strings.ReplaceAll(arch1.Url, manifest.Version, "$version")
-		}
-	} else {
-		for name, val := range arch {
-			manifest.Archtectures[name] = val
-			manifest.Version = strings.TrimPrefix(tag, "v")
-
-			autoupdate := strings.ReplaceAll(val.Url, manifest.Version, "$version")
-			bits := getBits(val.Url)
-			if manifest.AutoUpdate != nil {
-				manifest.AutoUpdate.Archtectures[bits] = &Archtecture{Url: autoupdate}
-			}
-		}
+	release, err := g.Release(ctx, repo)
+	if err != nil {
+		return err
 	}
-	if desc, err := github.GetDescription(owner, repos, os.Stderr); err == nil {
-		if manifest.Description == "" {
-			description := desc.Description
-			if description == "" {
-				fmt.Fprintln(os.Stderr, `Warning: "description" field on GitHub is empty`)
-			}
-			manifest.Description = description
-		}
-		if manifest.License == "" {
-			license := desc.License["name"]
-			if license == "" {
-				fmt.Fprintln(os.Stderr, `Warning: "license" field on GitHub is empty`)
-			}
-			manifest.License = license
-		}
+	manifest, err := g.GenerateRelease(ctx, release)
+	if err != nil {
+		return err
 	}
-
-	jsonBin, err := json.MarshalIndent(manifest, "", "    ")
+	jsonBin, err := manifest.Format()
 	if err != nil {
 		return err
 	}
-	return writeWithCRLF(jsonBin, os.Stdout)
+	warnInvalid("manifest", jsonBin)
+	_, err = stdout.Write(jsonBin)
+	return err
 }
 
 var version string
@@ -457,8 +241,17 @@ func main() {
 	fmt.Fprintf(os.Stderr, "%s %s for %s/%s by %s\n",
 		os.Args[0], version, runtime.GOOS, runtime.GOARCH, runtime.Version())
 
-	if err := mains(flag.Args()); err != nil {
+	if err := applyRepoConfig(os.Args[1:]); err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
+
+	if err := mains(flag.Args(), os.Stdout, http.DefaultClient); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		var ee *exitError
+		if errors.As(err, &ee) {
+			os.Exit(ee.code)
+		}
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,775 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"debug/pe"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hymkor/make-scoop-manifest/internal/flag"
+	"github.com/hymkor/make-scoop-manifest/scoop"
+)
+
+// fakeServer serves the canned responses of the APIs of the forges and the assets.
+// The requests to any host are sent to it as /HOST/PATH by fakeTransport.
+// The range requests are supported as the servers of GitHub do.
+type fakeServer struct {
+	files  map[string][]byte
+	mu     sync.Mutex
+	hits   map[string]int
+	client *http.Client
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.hits[r.URL.EscapedPath()]++
+	f.mu.Unlock()
+	bin, ok := f.files[r.URL.EscapedPath()]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found","documentation_url":"https://docs.github.com/rest"}`))
+		return
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(bin))
+}
+
+type fakeTransport struct {
+	server *url.URL
+}
+
+func (t *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fake := req.Clone(req.Context())
+	fake.URL.Path = "/" + req.URL.Host + req.URL.Path
+	fake.URL.RawPath = ""
+	if req.URL.RawPath != "" {
+		// The escaped slashes in the project paths of GitLab are kept
+		fake.URL.RawPath = "/" + req.URL.Host + req.URL.RawPath
+	}
+	fake.URL.Scheme = t.server.Scheme
+	fake.URL.Host = t.server.Host
+	fake.Host = t.server.Host
+	resp, err := http.DefaultTransport.RoundTrip(fake)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
+
+// makeZip returns a zip file containing files. The method Store and
+// the fixed timestamp make the same bytes every time. When machine is not 0,
+// the .exe files have the PE header for it.
+func makeZip(t *testing.T, files []string, machine uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Store,
+			Modified: time.Date(2024, 3, 23, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if machine != 0 && strings.HasSuffix(name, ".exe") {
+			w.Write(makePE(machine))
+		}
+		fmt.Fprintf(w, "dummy of %s\n", name)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	return buf.Bytes()
+}
+
+// makePE returns the DOS header and the PE header for the machine type
+func makePE(machine uint16) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("MZ")
+	buf.Write(make([]byte, 0x3C-2))
+	binary.Write(&buf, binary.LittleEndian, uint32(0x40))
+	buf.WriteString("PE\x00\x00")
+	binary.Write(&buf, binary.LittleEndian, pe.FileHeader{Machine: machine})
+	return buf.Bytes()
+}
+
+type testCase struct {
+	expect      string
+	args        []string
+	owner       string
+	repos       string
+	tag         string
+	description string
+	// assets are the files attached to the release and the files in them.
+	assets map[string][]string
+	// digest publishes the hashes of the assets as GitHub does
+	digest bool
+	// checksums is the name of the checksum file attached to the release
+	checksums string
+	// noDownload means that the assets should not be downloaded
+	// because their hashes are published and "bin" is given.
+	noDownload bool
+	// latest is the tag of the release GitHub marks as the latest
+	// when it is not tag.
+	latest string
+	// machine is the machine type of PE of the executables in the assets
+	machine uint16
+	// licenseFile is the license file of the repository whose license
+	// GitHub does not know
+	licenseFile string
+	// current is the manifest in testdata updated with -update.
+	// The result is read from the file updated instead of the standard output.
+	current string
+	// host is the host of the forge (default: github.com)
+	host string
+	// forge is "gitlab" or "gitea" for the forge at host, or "" for GitHub
+	forge string
+	// assetDir is the URL of the directory which has the assets
+	// instead of the release
+	assetDir string
+}
+
+var testCases = []testCase{
+	{
+		expect:      "goawk.json",
+		args:        []string{"benhoyt/goawk"},
+		digest:      true,
+		owner:       "benhoyt",
+		repos:       "goawk",
+		tag:         "v1.26.0",
+		description: "A POSIX-compliant AWK interpreter written in Go, with CSV support",
+		assets: map[string][]string{
+			"goawk_v1.26.0_darwin_amd64.tar.gz": nil,
+			"goawk_v1.26.0_linux_amd64.tar.gz":  nil,
+			"goawk_v1.26.0_windows_386.zip":     {"goawk.exe", "README.md", "LICENSE.txt"},
+			"goawk_v1.26.0_windows_amd64.zip":   {"goawk.exe", "README.md", "LICENSE.txt"},
+		},
+	},
+	{
+		expect:      "yShowVer.json",
+		args:        []string{"-anycpu", "zat-kaoru-hayama/yShowVer"},
+		owner:       "zat-kaoru-hayama",
+		repos:       "yShowVer",
+		tag:         "v2.0.0.6",
+		description: "Show the version number , timestamp and MD5SUM of Windows Executables (GUI: WindowsForms version)",
+		assets: map[string][]string{
+			"yShowVer-2.0.0.6.zip": {"yShowVer.exe", "yShowVer.exe.config"},
+		},
+	},
+	{
+		expect:      "twty.json",
+		args:        []string{"-p", "-license", "MIT", "mattn/twty"},
+		owner:       "mattn",
+		repos:       "twty",
+		tag:         "v0.0.13",
+		description: "command-line twitter client written in golang",
+		assets: map[string][]string{
+			"twty_v0.0.13_darwin_amd64.zip":  {"twty_v0.0.13_darwin_amd64/twty"},
+			"twty_v0.0.13_linux_amd64.zip":   {"twty_v0.0.13_linux_amd64/twty"},
+			"twty_v0.0.13_windows_amd64.zip": {"twty_v0.0.13_windows_amd64/twty.exe", "twty_v0.0.13_windows_amd64/README.md"},
+			"twty_v0.0.13_windows_arm64.zip": {"twty_v0.0.13_windows_arm64/twty.exe", "twty_v0.0.13_windows_arm64/README.md"},
+		},
+	},
+	{
+		expect:      "Download-Count.ps1.json",
+		args:        []string{"hymkor/Download-Count.ps1", "-binpattern", "*.ps1", "-anycpu"},
+		owner:       "hymkor",
+		repos:       "Download-Count.ps1",
+		tag:         "v0.2.0",
+		description: "A PowerShell script that reports how many assets on GitHub's Releases page have been downloaded.",
+		assets: map[string][]string{
+			"Download-Count.ps1-v0.2.0.zip": {"Download-Count.ps1", "README.md"},
+		},
+	},
+	{
+		expect:      "csvi.json",
+		args:        []string{"-inline", `{"bin":"csvi.exe"}`, "hymkor/csvi"},
+		owner:       "hymkor",
+		repos:       "csvi",
+		tag:         "v1.14.0",
+		description: "A terminal CSV editor",
+		assets: map[string][]string{
+			"csvi-v1.14.0-linux-amd64.tar.gz": nil,
+			"csvi-v1.14.0-windows-386.zip":    {"csvi.exe"},
+			"csvi-v1.14.0-windows-amd64.zip":  {"csvi.exe"},
+		},
+		checksums:  "csvi-v1.14.0-checksums.txt",
+		noDownload: true,
+	},
+	{
+		expect:      "monorepo.json",
+		args:        []string{"-tagregex", `^app/v(?<version>.+)$`, "o/mono"},
+		owner:       "o",
+		repos:       "mono",
+		tag:         "app/v1.2.0",
+		description: "The tools in a repository",
+		assets: map[string][]string{
+			"app-1.2.0-windows-amd64.zip": {"app.exe"},
+		},
+		latest: "tools/v9.9.9",
+	},
+	{
+		// The named group of -tagregex is $matchTag of "autoupdate"
+		expect:      "matchtag.json",
+		args:        []string{"-tagregex", `^(?<tag>[a-z]+)/v(?<version>[\d.]+)$`, "o/tools"},
+		owner:       "o",
+		repos:       "tools",
+		tag:         "cli/v0.5.0",
+		description: "The tools released with the prefixes of the tags",
+		assets: map[string][]string{
+			"cli-0.5.0-windows-amd64.zip": {"cli.exe"},
+		},
+	},
+	{
+		expect:      "bsky.json",
+		args:        []string{"-license", "MIT", "mattn/bsky"},
+		owner:       "mattn",
+		repos:       "bsky",
+		tag:         "v0.0.49",
+		description: "A cli application for bluesky social",
+		assets: map[string][]string{
+			"bsky-darwin-0.0.49.zip":  {"bsky"},
+			"bsky-linux-0.0.49.zip":   {"bsky"},
+			"bsky-windows-0.0.49.zip": {"bsky.exe"},
+		},
+		machine: pe.IMAGE_FILE_MACHINE_AMD64,
+	},
+	{
+		expect:      "proprietary.json",
+		args:        []string{"o/closed"},
+		owner:       "o",
+		repos:       "closed",
+		tag:         "v1.0.0",
+		description: "A tool whose license is not open",
+		assets: map[string][]string{
+			"closed-1.0.0-windows-amd64.zip": {"closed.exe"},
+		},
+		licenseFile: "Copyright (c) 2025 o\nAll rights reserved.\n\nThe redistribution is not permitted.\n",
+	},
+	{
+		// The files in msi packages can not be listed for "bin"
+		expect:      "msiapp.json",
+		args:        []string{"o/msiapp"},
+		owner:       "o",
+		repos:       "msiapp",
+		tag:         "v1.0.0",
+		description: "A tool installed with msi",
+		assets: map[string][]string{
+			"msiapp-1.0.0-windows-386.msi":   {"msiapp.exe"},
+			"msiapp-1.0.0-windows-amd64.msi": {"msiapp.exe"},
+		},
+	},
+	{
+		// GitLab allows the nested groups
+		expect:      "gitlab.json",
+		args:        []string{"https://gitlab.com/group/sub/labapp/-/releases"},
+		host:        "gitlab.com",
+		forge:       "gitlab",
+		owner:       "group/sub",
+		repos:       "labapp",
+		tag:         "v1.0.0",
+		description: "A tool on GitLab",
+		assets: map[string][]string{
+			"labapp-1.0.0-windows-amd64.zip": {"labapp.exe"},
+		},
+	},
+	{
+		// The tag without "v" is found as the tag with "v"
+		expect:      "gitea.json",
+		args:        []string{"-tag", "1.0.0", "https://codeberg.org/o/teaapp.git"},
+		host:        "codeberg.org",
+		forge:       "gitea",
+		owner:       "o",
+		repos:       "teaapp",
+		tag:         "v1.0.0",
+		description: "A tool on Codeberg",
+		assets: map[string][]string{
+			"teaapp-1.0.0-windows-386.zip":   {"teaapp.exe"},
+			"teaapp-1.0.0-windows-amd64.zip": {"teaapp.exe"},
+		},
+	},
+	{
+		// The forge of the unknown host is given with -forge
+		expect:      "forge.json",
+		args:        []string{"-forge", "gitea", "https://git.example.com/o/forgeapp"},
+		host:        "git.example.com",
+		forge:       "gitea",
+		owner:       "o",
+		repos:       "forgeapp",
+		tag:         "v2.1.0",
+		description: "A tool on the self-hosted Forgejo",
+		assets: map[string][]string{
+			"forgeapp-2.1.0-windows-amd64.zip": {"forgeapp.exe"},
+		},
+	},
+	{
+		// GitHub Enterprise Server
+		expect:      "ghes.json",
+		args:        []string{"-githubserver", "https://ghe.example.com/", "o/gheapp"},
+		host:        "ghe.example.com",
+		owner:       "o",
+		repos:       "gheapp",
+		tag:         "v0.3.0",
+		description: "A tool on GitHub Enterprise Server",
+		assets: map[string][]string{
+			"gheapp-0.3.0-windows-amd64.zip": {"gheapp.exe"},
+		},
+	},
+	{
+		// "checkver" is not written without "autoupdate"
+		expect:      "noversion.json",
+		args:        []string{"https://gitlab.com/o/noversion"},
+		host:        "gitlab.com",
+		forge:       "gitlab",
+		owner:       "o",
+		repos:       "noversion",
+		tag:         "v1.0.0",
+		description: "A tool whose assets have no versions in their URLs",
+		assets: map[string][]string{
+			"noversion-windows-amd64.zip": {"noversion.exe"},
+		},
+		assetDir: "https://example.com/files",
+	},
+	{
+		expect:      "dropped.json",
+		current:     "current/dropped.json",
+		owner:       "o",
+		repos:       "dropped",
+		tag:         "v2.0.0",
+		description: "A tool which stopped the 32bit builds",
+		assets: map[string][]string{
+			"dropped-2.0.0-windows-amd64.zip": {"dropped.exe"},
+		},
+	},
+}
+
+// newFakeServer starts the server for the test case
+// and returns the contents of the assets by their URLs.
+func newFakeServer(t *testing.T, tc *testCase) (*fakeServer, map[string][]byte) {
+	t.Helper()
+	fs := startFakeServer(t)
+	return fs, fs.addRelease(t, tc)
+}
+
+// startFakeServer starts the server without files until the test ends.
+// The requests of fs.client are sent to it.
+func startFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	fs := &fakeServer{files: map[string][]byte{}, hits: map[string]int{}}
+	server := httptest.NewServer(fs)
+	t.Cleanup(server.Close)
+	serverUrl, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	fs.client = &http.Client{Transport: &fakeTransport{server: serverUrl}}
+	return fs
+}
+
+// fakeAsset is an asset in the releases of GitHub and Gitea
+type fakeAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadUrl string `json:"browser_download_url"`
+	Digest             string `json:"digest,omitempty"`
+}
+
+// forgeRelease returns the release in the form of the API of the forge
+func forgeRelease(tc *testCase, tag string, prerelease bool, assets []*fakeAsset) map[string]any {
+	if tc.forge != "gitlab" {
+		return map[string]any{"tag_name": tag, "prerelease": prerelease, "assets": assets}
+	}
+	links := []map[string]string{}
+	for _, a := range assets {
+		links = append(links, map[string]string{
+			"name":             a.Name,
+			"url":              a.BrowserDownloadUrl,
+			"direct_asset_url": a.BrowserDownloadUrl,
+		})
+	}
+	return map[string]any{
+		"tag_name":         tag,
+		"upcoming_release": prerelease,
+		"assets":           map[string]any{"links": links},
+	}
+}
+
+// addRelease serves the repository and the release of the test case,
+// and returns the contents of the assets by their URLs.
+func (fs *fakeServer) addRelease(t *testing.T, tc *testCase) map[string][]byte {
+	t.Helper()
+	downloads := map[string][]byte{}
+
+	host := cmp.Or(tc.host, "github.com")
+	homepage := fmt.Sprintf("https://%s/%s/%s", host, tc.owner, tc.repos)
+	downloadUrl := func(name string) string {
+		if tc.assetDir != "" {
+			return tc.assetDir + "/" + name
+		}
+		if tc.forge == "gitlab" {
+			return fmt.Sprintf("%s/-/releases/%s/downloads/%s", homepage, tc.tag, name)
+		}
+		return fmt.Sprintf("%s/releases/download/%s/%s", homepage, tc.tag, name)
+	}
+	var assets []*fakeAsset
+	var checksums strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(tc.assets)) {
+		u := downloadUrl(name)
+		downloads[u] = makeZip(t, tc.assets[name], tc.machine)
+		fs.files[strings.TrimPrefix(u, "https:/")] = downloads[u]
+		hash := fmt.Sprintf("%x", sha256.Sum256(downloads[u]))
+		a := &fakeAsset{Name: name, BrowserDownloadUrl: u}
+		if tc.digest {
+			a.Digest = "sha256:" + hash
+		}
+		assets = append(assets, a)
+		fmt.Fprintf(&checksums, "%s  %s\n", hash, name)
+	}
+	if tc.checksums != "" {
+		u := downloadUrl(tc.checksums)
+		assets = append(assets, &fakeAsset{Name: tc.checksums, BrowserDownloadUrl: u})
+		fs.files[strings.TrimPrefix(u, "https:/")] = []byte(checksums.String())
+	}
+	release := forgeRelease(tc, tc.tag, false, assets)
+	releases := []map[string]any{
+		forgeRelease(tc, tc.tag+"-rc1", true, []*fakeAsset{}),
+		release,
+	}
+	latest := release
+	if tc.latest != "" {
+		latest = forgeRelease(tc, tc.latest, false, []*fakeAsset{})
+		releases = append([]map[string]any{latest}, releases...)
+	}
+
+	var api, latestApi, tagApi string
+	var repository any
+	switch tc.forge {
+	case "gitlab":
+		api = fmt.Sprintf("/%s/api/v4/projects/%s", host, url.PathEscape(tc.owner+"/"+tc.repos))
+		latestApi = api + "/releases/permalink/latest"
+		tagApi = api + "/releases/" + url.PathEscape(tc.tag)
+		repository = map[string]any{
+			"description": tc.description,
+			"license":     map[string]string{"key": "mit", "name": "MIT License"},
+			"license_url": homepage + "/-/blob/main/LICENSE",
+		}
+	case "gitea":
+		api = fmt.Sprintf("/%s/api/v1/repos/%s/%s", host, tc.owner, tc.repos)
+		latestApi = api + "/releases/latest"
+		tagApi = api + "/releases/tags/" + url.PathEscape(tc.tag)
+		repository = map[string]any{
+			"description": tc.description,
+			"licenses":    []string{"MIT"},
+		}
+	default:
+		api = fmt.Sprintf("/api.github.com/repos/%s/%s", tc.owner, tc.repos)
+		if host != "github.com" {
+			api = fmt.Sprintf("/%s/api/v3/repos/%s/%s", host, tc.owner, tc.repos)
+		}
+		latestApi = api + "/releases/latest"
+		tagApi = api + "/releases/tags/" + url.PathEscape(tc.tag)
+		license := map[string]string{"name": "MIT License", "spdx_id": "MIT"}
+		if tc.licenseFile != "" {
+			license = map[string]string{"name": "Other", "spdx_id": "NOASSERTION"}
+			bin, err := json.Marshal(map[string]string{
+				"html_url": homepage + "/blob/main/LICENSE",
+				"content":  base64.StdEncoding.EncodeToString([]byte(tc.licenseFile)),
+				"encoding": "base64",
+			})
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			fs.files[api+"/license"] = bin
+		}
+		repository = map[string]any{
+			"name":        tc.repos,
+			"description": tc.description,
+			"license":     license,
+		}
+	}
+	for path, v := range map[string]any{
+		api + "/releases": releases,
+		latestApi:         latest,
+		tagApi:            release,
+		api:               repository,
+	} {
+		bin, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		fs.files[path] = bin
+	}
+	return downloads
+}
+
+var rxHash = regexp.MustCompile(`"hash": "[0-9a-f]{64}"`)
+
+// isolate clears the environment variables which change the requests,
+// and makes the cache of the user unused.
+func isolate(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_SERVER_URL", "")
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("LocalAppData", t.TempDir())
+}
+
+func TestManifests(t *testing.T) {
+	isolate(t)
+
+	for i := range testCases {
+		tc := &testCases[i]
+		t.Run(tc.expect, func(t *testing.T) {
+			fs, downloads := newFakeServer(t, tc)
+
+			args := tc.args
+			var updated string
+			if tc.current != "" {
+				current, err := os.ReadFile(filepath.Join("testdata", tc.current))
+				if err != nil {
+					t.Fatal(err.Error())
+				}
+				updated = filepath.Join(t.TempDir(), filepath.Base(tc.current))
+				if err := os.WriteFile(updated, current, 0644); err != nil {
+					t.Fatal(err.Error())
+				}
+				args = append([]string{"-update", updated}, args...)
+			}
+			flag.Reset()
+			t.Cleanup(flag.Reset)
+			os.Args = append([]string{"make-scoop-manifest"}, args...)
+			flag.Parse()
+
+			var stdout bytes.Buffer
+			if err := mains(flag.Args(), &stdout, fs.client); err != nil {
+				t.Fatal(err.Error())
+			}
+			result := stdout.Bytes()
+			if updated != "" {
+				var err error
+				if result, err = os.ReadFile(updated); err != nil {
+					t.Fatal(err.Error())
+				}
+			}
+			expect, err := os.ReadFile(filepath.Join("testdata", tc.expect))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			// The hashes are compared with the served zip files below
+			// because the zip files are not the real ones.
+			masked := rxHash.ReplaceAll(result, []byte(`"hash": "*"`))
+			expect = rxHash.ReplaceAll(expect, []byte(`"hash": "*"`))
+			if !bytes.Equal(masked, expect) {
+				t.Fatalf("expect\n%s\nbut\n%s", expect, result)
+			}
+
+			if err := scoop.Validate(result); err != nil {
+				t.Fatal(err.Error())
+			}
+			var manifest scoop.Manifest
+			if err := json.Unmarshal(result, &manifest); err != nil {
+				t.Fatal(err.Error())
+			}
+			check := func(u, hash string) {
+				if h := fmt.Sprintf("%x", sha256.Sum256(downloads[u])); h != hash {
+					t.Fatalf("%s: expect hash %s, but %s", u, h, hash)
+				}
+			}
+			if len(manifest.UrlForAnyCPU) > 0 {
+				check(manifest.UrlForAnyCPU.String(), manifest.HashForAnyCPU.String())
+			}
+			for _, arch := range manifest.Archtectures {
+				check(arch.Url.String(), arch.Hash.String())
+			}
+			if tc.noDownload {
+				for u := range downloads {
+					if n := fs.hits[strings.TrimPrefix(u, "https:/")]; n > 0 {
+						t.Fatalf("%s: downloaded %d times", u, n)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBucket(t *testing.T) {
+	isolate(t)
+	fs := startFakeServer(t)
+	for _, name := range []string{"goawk.json", "twty.json", "csvi.json", "dropped.json"} {
+		i := slices.IndexFunc(testCases, func(tc testCase) bool { return tc.expect == name })
+		fs.addRelease(t, &testCases[i])
+	}
+	dir := t.TempDir()
+	// csvi.json is already up to date, and dropped.json of the older
+	// version has 32bit which the new release does not have.
+	for name, source := range map[string]string{
+		"csvi.json":    "csvi.json",
+		"dropped.json": "current/dropped.json",
+	} {
+		current, err := os.ReadFile(filepath.Join("testdata", source))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), current, 0644); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	config := `{
+    "apps": [
+        {"repo": "benhoyt/goawk", "output": "goawk.json"},
+        {"repo": "mattn/twty", "output": "twty.json", "args": ["-p", "-license", "MIT"]},
+        {"repo": "hymkor/csvi", "output": "csvi.json", "template": {"bin": "csvi.exe"}},
+        {"repo": "o/dropped", "output": "dropped.json"},
+        {"repo": "o/missing", "output": "missing.json"}
+    ]
+}`
+	configPath := filepath.Join(dir, "bucket.json")
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	flag.Reset()
+	t.Cleanup(flag.Reset)
+
+	var summary bytes.Buffer
+	err := mains([]string{"bucket", configPath}, &summary, fs.client)
+	if err == nil || err.Error() != "1 of 5 failed" {
+		t.Fatalf("expect that 1 of 5 failed, but %v", err)
+	}
+	for _, row := range []string{
+		`goawk\.json +benhoyt/goawk +updated +1\.26\.0`,
+		`twty\.json +mattn/twty +updated +0\.0\.13`,
+		`csvi\.json +hymkor/csvi +unchanged +1\.14\.0`,
+		`dropped\.json +o/dropped +updated +2\.0\.0`,
+		`missing\.json +o/missing +failed +\S`,
+	} {
+		if !regexp.MustCompile(`(?m)^` + row).Match(summary.Bytes()) {
+			t.Errorf("%s is not found in\n%s", row, summary.String())
+		}
+	}
+	for _, name := range []string{"goawk.json", "twty.json", "csvi.json", "dropped.json"} {
+		result, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		expect, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		masked := rxHash.ReplaceAll(result, []byte(`"hash": "*"`))
+		expect = rxHash.ReplaceAll(expect, []byte(`"hash": "*"`))
+		if !bytes.Equal(masked, expect) {
+			t.Errorf("%s: expect\n%s\nbut\n%s", name, expect, result)
+		}
+	}
+}
+
+func TestBucketArgs(t *testing.T) {
+	config := &bucketConfig{Args: []string{"-p", "-license", "MIT"}}
+	app := &bucketApp{Args: []string{"-license", "Apache-2.0", "-binpattern", "*.ps1"}}
+	t.Cleanup(flag.Reset)
+	for _, tc := range []struct {
+		args    []string
+		license string
+	}{
+		{nil, "Apache-2.0"},
+		{[]string{"-license", "GPL-3.0-or-later", "-nocache"}, "GPL-3.0-or-later"},
+	} {
+		flag.Reset()
+		if err := flag.ParseArgs(tc.args); err != nil {
+			t.Fatal(err.Error())
+		}
+		options, err := appOptions(newOptions(nil, nil, nil), config, app)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if options.License != tc.license || !options.ExtractDir || options.BinPattern != "*.ps1" {
+			t.Errorf("%v: expect -license %s, -p and -binpattern *.ps1, but %+v", tc.args, tc.license, options)
+		}
+	}
+}
+
+// chdir changes the working directory until the test ends
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestRepoConfig(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err.Error())
+	}
+	config := `{
+    "args": ["-p", "-license", "MIT", "-binpattern", "*.ps1"],
+    "template": {"notes": "hello"}
+}`
+	if err := os.WriteFile(filepath.Join(root, configName), []byte(config), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	sub := filepath.Join(root, "cmd", "app")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err.Error())
+	}
+	chdir(t, sub)
+
+	flag.Reset()
+	t.Cleanup(flag.Reset)
+	t.Cleanup(func() { configTemplate = nil })
+	args := []string{"-license", "Apache-2.0", "o/app"}
+	if err := flag.ParseArgs(args); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := applyRepoConfig(args); err != nil {
+		t.Fatal(err.Error())
+	}
+	// The options of the command line override the ones of the file
+	if *flagLicense != "Apache-2.0" {
+		t.Errorf("expect -license Apache-2.0, but %q", *flagLicense)
+	}
+	if !*flagExtractDir || *flagBinPattern != "*.ps1" {
+		t.Errorf("expect -p -binpattern *.ps1, but %v %q", *flagExtractDir, *flagBinPattern)
+	}
+	if a := flag.Args(); !slices.Equal(a, []string{"o/app"}) {
+		t.Errorf("expect the arguments [o/app], but %v", a)
+	}
+	if template, err := readTemplate(); err != nil || string(template) != `{"notes": "hello"}` {
+		t.Errorf("expect the template of the file, but %q %v", template, err)
+	}
+
+	flag.Reset()
+	args = []string{"-noconfig", "o/app"}
+	if err := flag.ParseArgs(args); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := applyRepoConfig(args); err != nil {
+		t.Fatal(err.Error())
+	}
+	if *flagExtractDir {
+		t.Error("expect that the file is not read with -noconfig")
+	}
+}
@@ -0,0 +1,157 @@
+package scoop
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// arm32 is the architecture of 32bit ARM. Scoop does not support it,
+// so the assets for it are ignored.
+const arm32 = "arm"
+
+// keywordsArm32 are the words in the names of the assets for 32bit ARM
+const keywordsArm32 = "arm,arm32,armv6,armv7,armhf,armel"
+
+// osKeywords are the keywords which may be the names of Windows itself
+// as win32 of Electron's "win32-x64". They score less than the other words,
+// so that the words after them decide the architecture.
+var osKeywords = []string{"win32", "win64"}
+
+// archOrder is the order of the architectures in the messages
+var archOrder = []string{"64bit", "32bit", "arm64", arm32}
+
+// tokenize splits the asset name into the lower-cased words
+// separated with the characters which are neither letters nor digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// archKeyword is a keyword split into the words
+type archKeyword struct {
+	arch   string
+	tokens []string
+	// score is what the keyword equal to the words in the name scores
+	score int
+}
+
+// archClassifier guesses the architecture from the words in the asset names
+type archClassifier struct {
+	keywords []archKeyword
+	// fallback are the architectures given the empty keyword,
+	// which match all the names
+	fallback []string
+}
+
+func newArchClassifier(keywords map[string]string) *archClassifier {
+	c := &archClassifier{}
+	for _, arch := range archOrder {
+		list, ok := keywords[arch]
+		if !ok {
+			continue
+		}
+		for _, keyword := range strings.Split(list, ",") {
+			tokens := tokenize(keyword)
+			if len(tokens) == 0 {
+				if strings.TrimSpace(keyword) == "" {
+					c.fallback = append(c.fallback, arch)
+				}
+				continue
+			}
+			score := 2
+			if len(tokens) == 1 && slices.Contains(osKeywords, tokens[0]) {
+				score = 1
+			}
+			c.keywords = append(c.keywords, archKeyword{arch: arch, tokens: tokens, score: score})
+		}
+	}
+	// The longer keywords like x86_64 are tried before x86.
+	slices.SortStableFunc(c.keywords, func(a, b archKeyword) int {
+		return len(b.tokens) - len(a.tokens)
+	})
+	return c
+}
+
+// scores returns the score of each architecture for the name.
+// A keyword equal to the words in the name scores 2 (1 for osKeywords)
+// and a keyword with digits in a word (like 386 in i386) scores 1.
+func (c *archClassifier) scores(name string) map[string]int {
+	scores := map[string]int{}
+	tokens := tokenize(name)
+	for i := 0; i < len(tokens); {
+		matched := false
+		for _, k := range c.keywords {
+			if len(tokens)-i >= len(k.tokens) && slices.Equal(tokens[i:i+len(k.tokens)], k.tokens) {
+				scores[k.arch] += k.score
+				i += len(k.tokens)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		var longest *archKeyword
+		for j, k := range c.keywords {
+			if len(k.tokens) == 1 && strings.ContainsFunc(k.tokens[0], unicode.IsDigit) &&
+				strings.Contains(tokens[i], k.tokens[0]) &&
+				(longest == nil || len(k.tokens[0]) > len(longest.tokens[0])) {
+				longest = &c.keywords[j]
+			}
+		}
+		if longest != nil {
+			scores[longest.arch]++
+		}
+		i++
+	}
+	return scores
+}
+
+// classify returns the architecture with the highest score for the name.
+// When some architectures have the same score, they are returned as ties
+// and the architecture is "".
+func (c *archClassifier) classify(name string) (arch string, ties []string) {
+	scores := c.scores(name)
+	best := 0
+	for _, a := range archOrder {
+		switch score := scores[a]; {
+		case score > best:
+			best = score
+			ties = []string{a}
+		case score == best && score > 0:
+			ties = append(ties, a)
+		}
+	}
+	if best == 0 {
+		ties = c.fallback
+	}
+	if len(ties) == 1 {
+		return ties[0], nil
+	}
+	return "", ties
+}
+
+// archUnknown is returned by getBits for the names without the words
+// for the architectures. The architecture is read from the executables.
+const archUnknown = "unknown"
+
+// getBits returns the architecture of the asset for "architecture"
+// of the manifest, archUnknown when the name tells nothing about it,
+// or "" when it is ambiguous or not supported by Scoop.
+func (g *Generator) getBits(name string) string {
+	arch, ties := g.archs.classify(name)
+	switch {
+	case len(ties) > 0:
+		fmt.Fprintf(g.log, "Warning: %s: ignored because the architecture is ambiguous: %s\n", name, strings.Join(ties, " or "))
+		return ""
+	case arch == arm32:
+		fmt.Fprintf(g.log, "%s: ignored because Scoop does not support 32bit ARM\n", name)
+		return ""
+	case arch == "":
+		return archUnknown
+	}
+	return arch
+}
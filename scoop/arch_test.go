@@ -0,0 +1,106 @@
+package scoop
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestArchClassifier(t *testing.T) {
+	options := NewOptions()
+	c := newArchClassifier(map[string]string{
+		"64bit": options.Keywords64,
+		"32bit": options.Keywords32,
+		"arm64": options.KeywordsArm64,
+		arm32:   keywordsArm32,
+	})
+	for _, tc := range []struct {
+		name   string
+		expect string
+		ties   []string
+	}{
+		{name: "app-1.0-windows-amd64.zip", expect: "64bit"},
+		{name: "app_1.0_Windows_x86_64.zip", expect: "64bit"},
+		{name: "app-1.0-win-x86-64.zip", expect: "64bit"},
+		{name: "app-1.0-windows-x86.zip", expect: "32bit"},
+		{name: "app-1.0-windows-i386.zip", expect: "32bit"},
+		{name: "app-1.0-win-ia32.7z", expect: "32bit"},
+		{name: "app-1.0-windows-aarch64.zip", expect: "arm64"},
+		{name: "app-1.0-windows-arm64.zip", expect: "arm64"},
+		{name: "app-1.0-windows-armv7.zip", expect: arm32},
+		{name: "charm-1.0-windows-x64.zip", expect: "64bit"},
+		{name: "app-1.0-windows.zip", expect: ""},
+		{name: "app-win-x64-arm64.zip", ties: []string{"64bit", "arm64"}},
+		// win32 may be the name of the OS
+		{name: "app-1.0-win32-x64.zip", expect: "64bit"},
+		{name: "app-1.0-win32-arm64.zip", expect: "arm64"},
+		{name: "app-1.0-win32-ia32.zip", expect: "32bit"},
+		{name: "app-1.0-win32.zip", expect: "32bit"},
+	} {
+		arch, ties := c.classify(tc.name)
+		if arch != tc.expect || !slices.Equal(ties, tc.ties) {
+			t.Errorf("%s: expect %q %v, but %q %v", tc.name, tc.expect, tc.ties, arch, ties)
+		}
+	}
+
+	// The empty keyword matches all the names which have no other keywords
+	c = newArchClassifier(map[string]string{"64bit": "", "32bit": "386"})
+	if arch, _ := c.classify("bsky-windows-0.0.49.zip"); arch != "64bit" {
+		t.Errorf("expect 64bit, but %q", arch)
+	}
+}
+
+// makeTestPE returns the headers of PE for the machine type
+func makeTestPE(machine uint16) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("MZ")
+	buf.Write(make([]byte, 0x3C-2))
+	binary.Write(&buf, binary.LittleEndian, uint32(0x40))
+	buf.WriteString("PE\x00\x00")
+	binary.Write(&buf, binary.LittleEndian, pe.FileHeader{Machine: machine})
+	buf.Write(make([]byte, 512))
+	return buf.Bytes()
+}
+
+func TestReadMachine(t *testing.T) {
+	for _, tc := range []struct {
+		bin    []byte
+		expect string
+	}{
+		{bin: makeTestPE(pe.IMAGE_FILE_MACHINE_AMD64), expect: "64bit"},
+		{bin: makeTestPE(pe.IMAGE_FILE_MACHINE_I386), expect: "32bit"},
+		{bin: makeTestPE(pe.IMAGE_FILE_MACHINE_ARM64), expect: "arm64"},
+		{bin: []byte("Write-Host hello\n"), expect: ""},
+		// e_lfanew points out of the head
+		{bin: append([]byte("MZ"), bytes.Repeat([]byte{0xFF}, 0x40)...), expect: ""},
+	} {
+		open := func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(tc.bin)), nil
+		}
+		machine, err := readMachine(open)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if machine != tc.expect {
+			t.Errorf("expect %q, but %q", tc.expect, machine)
+		}
+	}
+}
+
+func TestCheckMachines(t *testing.T) {
+	options := NewOptions()
+	machines := map[string]string{"app.exe": "32bit"}
+	if err := New(options).checkMachines("app-windows-amd64.zip", "64bit", machines); err != nil {
+		t.Fatalf("expect a warning, but %s", err.Error())
+	}
+	options.Strict = true
+	if err := New(options).checkMachines("app-windows-amd64.zip", "64bit", machines); err == nil {
+		t.Fatal("expect an error with Strict")
+	}
+	if err := New(options).checkMachines("app-windows-386.zip", "32bit", machines); err != nil {
+		t.Fatal(err.Error())
+	}
+}
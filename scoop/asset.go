@@ -0,0 +1,223 @@
+package scoop
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/hymkor/make-scoop-manifest/internal/archive"
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// matchBinPattern reports whether the entry name in an archive matches
+// the pattern. As filepath.Match on Windows does, "*" matches also
+// the directory part so that the result does not depend on the OS.
+func matchBinPattern(pattern, name string) bool {
+	if matched, err := path.Match(pattern, name); err == nil && matched {
+		return true
+	}
+	if strings.Contains(pattern, "/") {
+		return false
+	}
+	matched, err := path.Match(pattern, path.Base(name))
+	return err == nil && matched
+}
+
+// executables collects the names of the files matching Options.BinPattern
+// in an archive.
+type executables struct {
+	patterns   []string
+	extractDir bool
+	found      map[string]struct{}
+	// dir is the parent directory of them for "extract_dir" with Options.ExtractDir
+	dir string
+	// files are all the files in the archive for the cache
+	files []string
+	// peCheck reads the machine types of the executables into machines
+	peCheck  bool
+	machines map[string]string
+	// license is the text of the license file like LICENSE in the archive
+	license []byte
+}
+
+func (g *Generator) newExecutables(found map[string]struct{}) *executables {
+	return &executables{
+		patterns:   strings.Split(strings.ToLower(g.options.BinPattern), ","),
+		extractDir: g.options.ExtractDir,
+		found:      found,
+		peCheck:    g.options.PECheck,
+	}
+}
+
+func (e *executables) walk(name string, open archive.Opener) error {
+	e.files = append(e.files, name)
+	if e.license == nil && open != nil && isLicenseFile(name) {
+		text, err := readLicense(open)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		e.license = text
+	}
+	lowerName := strings.ToLower(name)
+	for _, pattern := range e.patterns {
+		if matchBinPattern(pattern, lowerName) {
+			nm := name
+			if e.extractDir {
+				notDir := path.Dir(nm)
+				if notDir != "." {
+					e.dir = notDir
+					nm = path.Base(nm)
+				}
+			}
+			e.found[nm] = struct{}{}
+			if e.peCheck && open != nil {
+				machine, err := readMachine(open)
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				e.addMachine(nm, machine)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// addMachine records the architecture of the executable unless it is ""
+func (e *executables) addMachine(name, machine string) {
+	if machine == "" {
+		return
+	}
+	if e.machines == nil {
+		e.machines = map[string]string{}
+	}
+	e.machines[name] = machine
+}
+
+// canList reports whether the executables in the asset can be listed.
+func (g *Generator) canList(name string) bool {
+	return archive.IsArchive(archive.Kind(name))
+}
+
+// warnMsi warns once in a run that "bin" has to be given with the
+// template when some of assets are msi packages, whose files can not be listed.
+func (g *Generator) warnMsi(assets []*forge.Asset) {
+	for _, asset1 := range assets {
+		if archive.Kind(asset1.Name) == ".msi" {
+			g.msiWarning.Do(func() {
+				fmt.Fprintln(g.log, "Warning: the files in msi packages can not be listed. Give \"bin\" with the template")
+			})
+			return
+		}
+	}
+}
+
+// newArchtecture makes the architecture entry for the asset.
+// A bare executable is renamed to exeName with the "#/" form of the URL.
+func newArchtecture(url, name, hash, exeName string, e *executables) *Archtecture {
+	if archive.Kind(name) == ".exe" {
+		if !strings.EqualFold(name, exeName) {
+			url = url + "#/" + exeName
+		}
+		e.found[exeName] = struct{}{}
+		return &Archtecture{
+			Url:  Strings{url},
+			Hash: stringsOf(hash),
+		}
+	}
+	return &Archtecture{
+		Url:        Strings{url},
+		Hash:       stringsOf(hash),
+		ExtractDir: stringsOf(e.dir),
+	}
+}
+
+func (g *Generator) isWindowsAssetName(name string) bool {
+	for _, word := range strings.Split(g.options.IgnoreWords, ",") {
+		if strings.Contains(name, word) {
+			fmt.Fprintf(g.log, "%s: ignored because it contains %s\n", name, word)
+			return false
+		}
+	}
+	return true
+}
+
+// inspected is the result of inspectAssets for an asset
+type inspected struct {
+	arch *Archtecture
+	// executables are the names of the executables for "bin"
+	executables map[string]struct{}
+	// machines are the architectures of the executables read from
+	// their headers
+	machines map[string]string
+	// license is the text of the license file in the asset
+	license []byte
+}
+
+// inspectAssets downloads or reads the assets with Options.Jobs workers at
+// the same time and returns the results in the order of assets.
+// The assets whose hashes are published are not downloaded unless
+// needInside is true and the files in them can be listed.
+// The headers of the executables are read for the assets in needMachine
+// and for all the assets with Options.PECheck.
+func (g *Generator) inspectAssets(ctx context.Context, assets []*forge.Asset, checksums map[*forge.Asset]*checksum, needInside bool, needMachine map[*forge.Asset]bool, exeName string) ([]*inspected, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := make([]*inspected, len(assets))
+	var firstErr error
+	var errOnce sync.Once
+
+	semaphore := make(chan struct{}, max(g.options.Jobs, 1))
+	var wg sync.WaitGroup
+	for i, asset1 := range assets {
+		result[i] = &inspected{executables: map[string]struct{}{}}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if ctx.Err() != nil {
+				return
+			}
+			var published string
+			if c := checksums[asset1]; c != nil {
+				published = c.hash
+			}
+			e := g.newExecutables(result[i].executables)
+			e.peCheck = e.peCheck || needMachine[asset1]
+			inside := (needInside || e.peCheck) && g.canList(asset1.Name)
+			if e.peCheck && archive.Kind(asset1.Name) == ".exe" {
+				inside = true
+			}
+			var err error
+			fullpath, isLocal := g.options.LocalFiles[asset1.Name]
+			switch {
+			case isLocal:
+				fmt.Fprintln(g.log, "Read local file:", fullpath)
+				result[i].arch, err = g.readFileAndGetArchitecture(asset1.Url, fullpath, exeName, e)
+			case published != "" && !inside:
+				fmt.Fprintf(g.log, "%s: use the published hash without downloading\n", asset1.Name)
+				result[i].arch = newArchtecture(asset1.Url, asset1.Name, published, exeName, e)
+			default:
+				fmt.Fprintln(g.log, "Download:", asset1.Url)
+				result[i].arch, err = g.downloadAndGetArchitecture(ctx, asset1.Url, asset1.Name, published, exeName, e)
+			}
+			result[i].machines = e.machines
+			result[i].license = e.license
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
@@ -0,0 +1,192 @@
+package scoop
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// checksum is the hash of an asset published by the forge or in a checksum file
+type checksum struct {
+	hash string
+	// file is the checksum file or nil for the digest of the forge
+	file *forge.Asset
+	// regex is the pattern for "autoupdate" to find the hash in file.
+	// When it is "", Scoop finds the hash by itself.
+	regex string
+	// ext is "hash" of "autoupdate" verified with the checksum file
+	ext *HashExtraction
+}
+
+// extraction returns "hash" of "autoupdate" for the checksum file
+// or nil when the hash is not in a checksum file.
+func (c *checksum) extraction() HashExtractions {
+	if c == nil || c.ext == nil {
+		return nil
+	}
+	return HashExtractions{c.ext}
+}
+
+var (
+	// rxChecksumFile matches the checksum files for all the assets
+	// like checksums.txt, SHA256SUMS and APP_1.0_checksums.txt
+	rxChecksumFile = regexp.MustCompile(`(?i)(?:^|[._-])(?:sha256sums?|checksums?)(?:\.txt)?$`)
+	// rxGnuChecksum matches the lines of sha256sum: "HASH  NAME" or "HASH *NAME"
+	rxGnuChecksum = regexp.MustCompile(`^([0-9a-fA-F]{64})\s+\*?(\S.*)$`)
+	// rxBsdChecksum matches the lines of BSD style: "SHA256 (NAME) = HASH"
+	rxBsdChecksum = regexp.MustCompile(`^SHA256\s*\((.+)\)\s*=\s*([0-9a-fA-F]{64})$`)
+	// rxHashOnly matches the files which have only the hash of an asset
+	rxHashOnly = regexp.MustCompile(`^([0-9a-fA-F]{64})$`)
+)
+
+// singleChecksumSuffixes are the suffixes of the checksum files for an asset
+var singleChecksumSuffixes = []string{".sha256", ".sha256sum", ".sha256.txt"}
+
+// parseChecksums returns the hashes in the checksum file and the patterns
+// for "autoupdate" by the names of the assets. The line which has only
+// a hash is for the asset name.
+func parseChecksums(text []byte, name string) map[string]*checksum {
+	result := map[string]*checksum{}
+	sc := bufio.NewScanner(bytes.NewReader(text))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if m := rxHashOnly.FindStringSubmatch(line); m != nil && name != "" {
+			result[name] = &checksum{hash: strings.ToLower(m[1])}
+		} else if m := rxGnuChecksum.FindStringSubmatch(line); m != nil {
+			fname := strings.TrimPrefix(m[2], "./")
+			regex := `$sha256\s+\*?$basename`
+			if strings.Contains(fname, "/") {
+				regex = `$sha256\s+\S*/$basename`
+			}
+			result[path.Base(fname)] = &checksum{hash: strings.ToLower(m[1]), regex: regex}
+		} else if m := rxBsdChecksum.FindStringSubmatch(line); m != nil {
+			result[path.Base(m[1])] = &checksum{
+				hash:  strings.ToLower(m[2]),
+				regex: `\(\S*$basename\)\s*=\s*$sha256`,
+			}
+		}
+	}
+	return result
+}
+
+// maxChecksumFileSize is the limit of the size of a checksum file read
+const maxChecksumFileSize = 1 << 20
+
+func (g *Generator) readChecksumFile(ctx context.Context, file *forge.Asset) ([]byte, error) {
+	if fullpath, ok := g.options.LocalFiles[file.Name]; ok {
+		return os.ReadFile(fullpath)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.Url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", file.Url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxChecksumFileSize))
+}
+
+// findChecksums returns the hashes of the assets published as the digests
+// of the forge or in the checksum files attached to the release.
+// The checksum files are read when some assets have no digests or
+// when "autoupdate" is made.
+func (g *Generator) findChecksums(ctx context.Context, repo *repository, release *Release, assets []*forge.Asset) map[*forge.Asset]*checksum {
+	version := release.Version()
+	result := map[*forge.Asset]*checksum{}
+	allDigests := true
+	for _, a := range assets {
+		if hash, ok := strings.CutPrefix(a.Digest, "sha256:"); ok && hash != "" {
+			result[a] = &checksum{hash: strings.ToLower(hash)}
+		} else {
+			allDigests = false
+		}
+	}
+	if allDigests && g.options.NoAutoUpdate {
+		return result
+	}
+	add := func(a, file *forge.Asset, text []byte, c *checksum) {
+		c.file = file
+		if !g.options.NoAutoUpdate {
+			ext := &HashExtraction{Regex: c.regex}
+			var err error
+			ext.Url, err = g.autoUpdateUrl(release, file.Url)
+			if err == nil {
+				err = ext.verify(text, file.Url, a.Url, version, c.hash, g.releaseVariables(release))
+			}
+			if err != nil {
+				fmt.Fprintf(g.log, "Warning: \"hash\" of \"autoupdate\" for %s is not written: %s\n", a.Name, err.Error())
+			} else {
+				c.ext = ext
+			}
+		}
+		if prev, ok := result[a]; ok {
+			if prev.hash != c.hash {
+				fmt.Fprintf(g.log, "Warning: %s: the hash in %s differs from the digest. The digest is used\n", a.Name, file.Name)
+				return
+			}
+			if prev.file != nil {
+				return
+			}
+		}
+		result[a] = c
+	}
+	for _, file := range release.release.Assets {
+		if !rxChecksumFile.MatchString(file.Name) {
+			continue
+		}
+		text, err := g.readChecksumFile(ctx, file)
+		if err != nil {
+			fmt.Fprintf(g.log, "Warning: %s\n", err.Error())
+			continue
+		}
+		fmt.Fprintln(g.log, "Read checksums:", file.Name)
+		sums := parseChecksums(text, "")
+		for _, a := range assets {
+			if c, ok := sums[a.Name]; ok {
+				add(a, file, text, c)
+			}
+		}
+	}
+	for _, file := range release.release.Assets {
+		for _, a := range assets {
+			if !isSingleChecksumFile(file.Name, a.Name) {
+				continue
+			}
+			text, err := g.readChecksumFile(ctx, file)
+			if err != nil {
+				fmt.Fprintf(g.log, "Warning: %s\n", err.Error())
+				continue
+			}
+			fmt.Fprintln(g.log, "Read checksums:", file.Name)
+			if c, ok := parseChecksums(text, a.Name)[a.Name]; ok {
+				add(a, file, text, c)
+			}
+		}
+	}
+	return result
+}
+
+// isSingleChecksumFile reports whether fname is the checksum file
+// only for the asset name like NAME.sha256
+func isSingleChecksumFile(fname, name string) bool {
+	for _, suffix := range singleChecksumSuffixes {
+		if strings.EqualFold(fname, name+suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package scoop
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	hash1 := strings.Repeat("a", 64)
+	hash2 := strings.Repeat("B", 64)
+	text := hash1 + "  foo-windows-amd64.zip\n" +
+		hash1 + " *dist/foo-windows-386.zip\n" +
+		"SHA256 (foo-windows-arm64.zip) = " + hash2 + "\n" +
+		strings.Repeat("c", 128) + "  foo-linux-amd64.tar.gz\n"
+	sums := parseChecksums([]byte(text), "")
+	for name, expect := range map[string]checksum{
+		"foo-windows-amd64.zip": {hash: hash1, regex: `$sha256\s+\*?$basename`},
+		"foo-windows-386.zip":   {hash: hash1, regex: `$sha256\s+\S*/$basename`},
+		"foo-windows-arm64.zip": {hash: strings.ToLower(hash2), regex: `\(\S*$basename\)\s*=\s*$sha256`},
+	} {
+		c, ok := sums[name]
+		if !ok {
+			t.Fatalf("%s: not found", name)
+		}
+		if c.hash != expect.hash || c.regex != expect.regex {
+			t.Fatalf("%s: expect %#v, but %#v", name, expect, *c)
+		}
+	}
+	if _, ok := sums["foo-linux-amd64.tar.gz"]; ok {
+		t.Fatal("expect SHA512 is ignored")
+	}
+
+	single := parseChecksums([]byte(hash1+"\n"), "foo.zip")
+	if c, ok := single["foo.zip"]; !ok || c.hash != hash1 || c.regex != "" {
+		t.Fatalf("expect the hash for foo.zip, but %#v", single)
+	}
+}
@@ -0,0 +1,172 @@
+package scoop
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// rxPlainTag matches the tags which "checkver": "github" reads correctly
+var rxPlainTag = regexp.MustCompile(`^[vV]?[\d.]+$`)
+
+// checkVerRule is a candidate of "checkver" with the emulation of Scoop
+type checkVerRule struct {
+	value any
+	// latest is true when Scoop reads the release the forge marks
+	// as the latest, and false when it reads the list of the releases.
+	latest bool
+	// rx is the regular expression of "regex" applied to text(tag)
+	rx *regexp.Regexp
+	// text returns the part of the page which Scoop reads for the tag
+	text func(tag string) string
+}
+
+// find returns the version which Scoop finds for the tag
+func (c *checkVerRule) find(tag string) string {
+	m := c.rx.FindStringSubmatch(c.text(tag))
+	if m == nil {
+		return ""
+	}
+	if i := c.rx.SubexpIndex("version"); i >= 0 {
+		return m[i]
+	}
+	if len(m) >= 2 {
+		return m[1]
+	}
+	return m[0]
+}
+
+// tagPattern returns the regular expression for the tags in the same
+// format as the tag of the release: the version is replaced with the group.
+// The named groups of Options.TagRegex are kept for $matchTag and so on
+// of "autoupdate", and then the version is captured as the group "version"
+// not to be confused with them.
+func (g *Generator) tagPattern(release *Release) string {
+	prefix, version, suffix, _ := g.tags.split(release.Tag)
+	class := `[\d.]+`
+	if strings.Trim(version, "0123456789.") != "" {
+		class = `[\w.+-]+`
+	}
+	groups := g.tags.groups(release.Tag)
+	if len(groups) == 0 {
+		return regexp.QuoteMeta(prefix) + "(" + class + ")" + regexp.QuoteMeta(suffix)
+	}
+	tag := release.Tag
+	var pattern strings.Builder
+	pos := 0
+	versionDone := false
+	writeVersion := func() {
+		pattern.WriteString(regexp.QuoteMeta(tag[pos:len(prefix)]))
+		pattern.WriteString("(?<version>" + class + ")")
+		pos = len(prefix) + len(version)
+		versionDone = true
+	}
+	for _, group := range groups {
+		if !versionDone && group.start >= len(prefix) {
+			writeVersion()
+		}
+		pattern.WriteString(regexp.QuoteMeta(tag[pos:group.start]))
+		pattern.WriteString("(?<" + group.name + ">" + regexp.QuoteMeta(tag[group.start:group.end]) + ")")
+		pos = group.end
+	}
+	if !versionDone {
+		writeVersion()
+	}
+	pattern.WriteString(regexp.QuoteMeta(tag[pos:]))
+	return pattern.String()
+}
+
+// checkVerRules returns the candidates of "checkver" for the release
+// in the order of preference.
+func (g *Generator) checkVerRules(release *Release) []*checkVerRule {
+	repo := release.repo
+	pattern := g.tagPattern(release)
+	homepage := repo.provider.Homepage(repo.owner, repo.repos)
+	var rules []*checkVerRule
+	if repo.provider.CheckVer(repo.owner, repo.repos, "") == "github" {
+		// Scoop reads "/releases/tag/TAG" in the page of the latest release
+		text := func(tag string) string {
+			return homepage + "/releases/tag/" + tag + `"`
+		}
+		// "checkver": "github" has no named groups for "autoupdate"
+		if rxPlainTag.MatchString(release.Tag) && g.tags.groups(release.Tag) == nil {
+			rules = append(rules, &checkVerRule{
+				value:  "github",
+				latest: true,
+				rx:     regexp.MustCompile(`/releases/tag/(?:v|V)?([\d.]+)`),
+				text:   text,
+			})
+		}
+		rules = append(rules, &checkVerRule{
+			value:  &forge.CheckVer{Github: homepage, Regex: "/releases/tag/" + pattern},
+			latest: true,
+			rx:     regexp.MustCompile("/releases/tag/" + pattern),
+			text:   text,
+		})
+	} else {
+		regex := "^" + pattern + "$"
+		rules = append(rules, &checkVerRule{
+			value:  repo.provider.CheckVer(repo.owner, repo.repos, regex),
+			latest: true,
+			rx:     regexp.MustCompile(regex),
+			text:   func(tag string) string { return tag },
+		})
+	}
+	// The tag of the newest release in the format is read from the list
+	// when the latest release is not the one to track.
+	regex := `"tag_name":\s*"` + pattern + `"`
+	rules = append(rules, &checkVerRule{
+		value: &forge.CheckVer{Url: repo.provider.ReleasesApi(repo.owner, repo.repos), Regex: regex},
+		rx:    regexp.MustCompile(regex),
+		text:  func(tag string) string { return `"tag_name":"` + tag + `"` },
+	})
+	return rules
+}
+
+// checkVer returns "checkver" for the release verified with the releases
+// on the forge: the version Scoop finds with it must be the version of
+// the release. When no candidates pass, the last one is returned with
+// a warning.
+func (g *Generator) checkVer(ctx context.Context, release *Release) any {
+	repo := release.repo
+	rules := g.checkVerRules(release)
+	var latest, newest *forge.Release
+	var err error
+	for _, c := range rules {
+		if found := c.find(release.Tag); found != release.Version() {
+			fmt.Fprintf(g.log, "checkver %v: %q is found in %s instead of %q\n", c.rx, found, release.Tag, release.Version())
+			continue
+		}
+		if g.options.Tag != "" {
+			// The older release is used on purpose.
+			return c.value
+		}
+		var current *forge.Release
+		if c.latest {
+			if latest == nil && err == nil {
+				latest, err = repo.provider.GetLatestRelease(ctx, repo.owner, repo.repos, g.log)
+			}
+			current = latest
+		} else {
+			if newest == nil && err == nil {
+				newest, err = repo.provider.FindRelease(ctx, repo.owner, repo.repos, g.log, func(r *forge.Release) bool {
+					return !r.Draft && c.rx.MatchString(c.text(r.TagName))
+				})
+			}
+			current = newest
+		}
+		if err != nil {
+			fmt.Fprintf(g.log, "Warning: \"checkver\" is not verified: %s\n", err.Error())
+			return c.value
+		}
+		if current.TagName == release.Tag {
+			return c.value
+		}
+		fmt.Fprintf(g.log, "checkver %v: Scoop reads %s instead of %s\n", c.rx, current.TagName, release.Tag)
+	}
+	fmt.Fprintf(g.log, "Warning: Scoop may not find %s with \"checkver\"\n", release.Version())
+	return rules[len(rules)-1].value
+}
@@ -0,0 +1,320 @@
+package scoop
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hymkor/make-scoop-manifest/internal/archive"
+	"github.com/hymkor/make-scoop-manifest/internal/cache"
+)
+
+// errRangeNotSupported is returned when the server ignores the range requests
+var errRangeNotSupported = errors.New("range requests are not supported")
+
+// rangeChunkSize is the minimum size read with a range request.
+// The central directory of a zip file is read with a few requests.
+const rangeChunkSize = 64 * 1024
+
+// rangeReader reads the remote file with HTTP range requests
+// and keeps the last chunk read.
+type rangeReader struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+	size   int64
+
+	mu     sync.Mutex
+	offset int64
+	buffer []byte
+}
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+		if pos < r.offset || pos >= r.offset+int64(len(r.buffer)) {
+			if err := r.fetch(pos, len(p)-n); err != nil {
+				return n, err
+			}
+		}
+		n += copy(p[n:], r.buffer[pos-r.offset:])
+	}
+	return n, nil
+}
+
+func (r *rangeReader) fetch(pos int64, length int) error {
+	end := min(pos+max(int64(length), rangeChunkSize), r.size) - 1
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", pos, end))
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("%s: %w (%s)", r.url, errRangeNotSupported, resp.Status)
+	}
+	buffer, err := io.ReadAll(io.LimitReader(resp.Body, end-pos+1))
+	if err != nil {
+		return err
+	}
+	if len(buffer) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	r.offset = pos
+	r.buffer = buffer
+	return nil
+}
+
+// acceptsRanges reports whether the response tells that
+// the server accepts the range requests for the file.
+func acceptsRanges(resp *http.Response) bool {
+	return resp.Header.Get("Accept-Ranges") == "bytes" && resp.ContentLength > 0
+}
+
+func hashString(h hash.Hash) string {
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// hashStream reads r to the end and returns the hash of it.
+// The executables in a tar archive are listed on the way,
+// so it is not saved anywhere.
+func (g *Generator) hashStream(r io.Reader, name string, e *executables) (string, error) {
+	h := sha256.New()
+	tee := io.TeeReader(r, h)
+	if g.canList(name) {
+		if err := archive.WalkTar(tee, archive.Kind(name), e.walk); err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+	} else if e.peCheck && archive.Kind(name) == ".exe" {
+		head, err := readHead(tee)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		e.addMachine(name, parseMachine(head))
+	}
+	// The padding and the compressed data after the end of the tar
+	// have to be hashed too.
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return hashString(h), nil
+}
+
+// hashFile reads the zip or 7z archive fd to the end for the hash,
+// and lists the executables in it.
+func hashFile(fd *os.File, e *executables) (string, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, fd)
+	if err != nil {
+		return "", err
+	}
+	if err := archive.WalkReaderAt(fd, size, archive.Kind(fd.Name()), e.walk); err != nil {
+		return "", err
+	}
+	return hashString(h), nil
+}
+
+func (g *Generator) readFileAndGetArchitecture(url, fullpath, exeName string, e *executables) (*Archtecture, error) {
+	fd, err := os.Open(fullpath)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	name := filepath.Base(fullpath)
+	var hash string
+	if archive.IsTar(archive.Kind(name)) || !g.canList(name) {
+		hash, err = g.hashStream(fd, name, e)
+	} else {
+		hash, err = hashFile(fd, e)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return newArchtecture(url, name, hash, exeName, e), nil
+}
+
+// conditionalRequest returns the cache entry of the asset and sets the
+// headers to download it only when it is modified since then.
+// The entry is not used when the asset has to be saved with Options.DownloadTo.
+func (g *Generator) conditionalRequest(req *http.Request, url, name string, e *executables) *cache.Entry {
+	if g.cache == nil {
+		return nil
+	}
+	if g.options.DownloadTo != "" {
+		if _, err := os.Stat(filepath.Join(g.options.DownloadTo, name)); err != nil {
+			return nil
+		}
+	}
+	entry, ok := g.cache.Get(url)
+	if !ok || (e.peCheck && !entry.PECheck) {
+		return nil
+	}
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return entry
+}
+
+// putCache records the downloaded asset when the response has
+// the validator for the conditional requests.
+func (g *Generator) putCache(resp *http.Response, url string, size int64, hash string, e *executables) {
+	if g.cache == nil {
+		return
+	}
+	entry := &cache.Entry{
+		Url:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Size:         size,
+		Sha256:       hash,
+		Files:        e.files,
+		Machines:     e.machines,
+		PECheck:      e.peCheck,
+		License:      string(e.license),
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return
+	}
+	if err := g.cache.Put(entry); err != nil {
+		fmt.Fprintf(g.log, "Warning: %s: %s\n", url, err.Error())
+	}
+}
+
+// downloadAndGetArchitecture downloads the asset only once while hashing it.
+// The tar archives are listed on the way. For zip archives, the central
+// directory is read with range requests before the body is read when
+// the server supports them. Otherwise, the zip and 7z archives are saved
+// into a temporary file to be listed.
+// When the asset is not modified since it was recorded in the cache,
+// it is not downloaded. When the hash is published, the body of a zip
+// archive is not read after the central directory is read, and
+// the hash of the downloaded body is verified with it. Only the assets
+// whose hashes are verified or computed are recorded in the cache.
+func (g *Generator) downloadAndGetArchitecture(ctx context.Context, url, name, published, exeName string, e *executables) (*Archtecture, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	cached := g.conditionalRequest(req, url, name, e)
+	resp, err := g.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		fmt.Fprintf(g.log, "%s: not modified since the last download\n", name)
+		if g.canList(name) {
+			for _, f := range cached.Files {
+				e.walk(f, nil)
+			}
+		}
+		e.machines = cached.Machines
+		if cached.License != "" {
+			e.license = []byte(cached.License)
+		}
+		if err := g.cache.Put(cached); err != nil {
+			fmt.Fprintf(g.log, "Warning: %s: %s\n", url, err.Error())
+		}
+		return newArchtecture(url, name, cached.Sha256, exeName, e), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	progress := newProgressReader(resp.Body, name, resp.ContentLength, g.log)
+	var body io.Reader = progress
+
+	var saved *os.File
+	if g.options.DownloadTo != "" {
+		fname := filepath.Join(g.options.DownloadTo, name)
+		saved, err = os.Create(fname)
+		if err != nil {
+			return nil, err
+		}
+		defer saved.Close()
+		fmt.Fprintln(g.log, "Save as", fname)
+		body = io.TeeReader(body, saved)
+	}
+	done := func(hash string) (*Archtecture, error) {
+		if published != "" && hash != published {
+			return nil, fmt.Errorf("%s: the hash %s differs from the published one %s", name, hash, published)
+		}
+		g.putCache(resp, url, max(progress.n, resp.ContentLength), hash, e)
+		return newArchtecture(url, name, hash, exeName, e), nil
+	}
+
+	kind := archive.Kind(name)
+	if archive.IsTar(kind) || !g.canList(name) {
+		hash, err := g.hashStream(body, name, e)
+		if err != nil {
+			return nil, err
+		}
+		return done(hash)
+	}
+	if kind == ".zip" && saved == nil && acceptsRanges(resp) {
+		remote := &rangeReader{
+			ctx:    ctx,
+			client: g.client(),
+			// The URL redirected to is used not to be redirected again
+			url:  resp.Request.URL.String(),
+			size: resp.ContentLength,
+		}
+		err := archive.WalkReaderAt(remote, remote.size, kind, e.walk)
+		if err == nil && published != "" {
+			// The published hash is not verified without the body,
+			// so it is not recorded in the cache.
+			return newArchtecture(url, name, published, exeName, e), nil
+		}
+		if err == nil {
+			h := sha256.New()
+			if _, err := io.Copy(h, body); err != nil {
+				return nil, fmt.Errorf("%s: %w", url, err)
+			}
+			return done(hashString(h))
+		}
+		if !errors.Is(err, errRangeNotSupported) {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Fprintf(g.log, "%s: %s. Download the whole file\n", name, err.Error())
+	}
+	if saved == nil {
+		saved, err = os.CreateTemp("", "make-scoop-manifest-*"+kind)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(saved.Name())
+		defer saved.Close()
+		body = io.TeeReader(body, saved)
+	}
+	h := sha256.New()
+	size, err := io.Copy(h, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	if err := archive.WalkReaderAt(saved, size, kind, e.walk); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return done(hashString(h))
+}
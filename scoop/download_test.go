@@ -0,0 +1,189 @@
+package scoop
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+func makeTestZip(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		fmt.Fprintf(w, "dummy of %s\n", name)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	return buf.Bytes()
+}
+
+func makeTestTarGz(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		body := fmt.Sprintf("dummy of %s\n", name)
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(body)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err.Error())
+		}
+		tw.Write([]byte(body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	return buf.Bytes()
+}
+
+func TestDownloadAndGetArchitecture(t *testing.T) {
+	files := map[string][]byte{
+		"/foo-windows-amd64.zip":    makeTestZip(t, "foo/foo.exe", "foo/README.md"),
+		"/foo-windows-amd64.tar.gz": makeTestTarGz(t, "foo/foo.exe", "foo/README.md"),
+	}
+	for _, ranges := range []bool{true, false} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bin := files[r.URL.Path]
+			if ranges {
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(bin))
+			} else {
+				w.Write(bin)
+			}
+		}))
+		for name, bin := range files {
+			options := NewOptions()
+			options.ExtractDir = true
+			options.CacheDir = ""
+			g := New(options)
+			found := map[string]struct{}{}
+			arch, err := g.downloadAndGetArchitecture(context.Background(), server.URL+name, name[1:], "", "foo.exe", g.newExecutables(found))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if expect := fmt.Sprintf("%x", sha256.Sum256(bin)); arch.Hash.String() != expect {
+				t.Fatalf("%s: expect hash %s, but %s", name, expect, arch.Hash)
+			}
+			if _, ok := found["foo.exe"]; !ok || len(found) != 1 || arch.ExtractDir.String() != "foo" {
+				t.Fatalf("%s: expect foo/foo.exe, but %#v in %s", name, found, arch.ExtractDir)
+			}
+
+			local := filepath.Join(t.TempDir(), name[1:])
+			if err := os.WriteFile(local, bin, 0644); err != nil {
+				t.Fatal(err.Error())
+			}
+			localArch, err := g.readFileAndGetArchitecture(server.URL+name, local, "foo.exe", g.newExecutables(map[string]struct{}{}))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if !reflect.DeepEqual(localArch, arch) {
+				t.Fatalf("%s: expect %#v, but %#v", name, arch, localArch)
+			}
+		}
+		server.Close()
+	}
+}
+
+func TestDownloadCache(t *testing.T) {
+	// foo.exe is not a PE executable, so no machine is found with -pecheck
+	bin := makeTestTarGz(t, "foo/foo.exe")
+	const etag = `"v1"`
+	for _, peCheck := range []bool{false, true} {
+		downloads := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			downloads++
+			w.Header().Set("ETag", etag)
+			w.Write(bin)
+		}))
+
+		options := NewOptions()
+		options.CacheDir = t.TempDir()
+		options.PECheck = peCheck
+		g := New(options)
+		url := server.URL + "/foo-windows-amd64.tar.gz"
+		var archs []*Archtecture
+		for range 2 {
+			found := map[string]struct{}{}
+			arch, err := g.downloadAndGetArchitecture(context.Background(), url, "foo-windows-amd64.tar.gz", "", "foo.exe", g.newExecutables(found))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if _, ok := found["foo/foo.exe"]; !ok {
+				t.Fatalf("expect foo/foo.exe, but %#v", found)
+			}
+			archs = append(archs, arch)
+		}
+		server.Close()
+		if downloads != 1 {
+			t.Fatalf("pecheck=%v: expect 1 download, but %d", peCheck, downloads)
+		}
+		if !reflect.DeepEqual(archs[0], archs[1]) {
+			t.Fatalf("pecheck=%v: expect %#v, but %#v", peCheck, archs[0], archs[1])
+		}
+	}
+}
+
+func TestDownloadCacheUnverified(t *testing.T) {
+	bin := makeTestZip(t, "foo/foo.exe")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(bin))
+	}))
+	defer server.Close()
+
+	options := NewOptions()
+	options.CacheDir = t.TempDir()
+	g := New(options)
+	url := server.URL + "/foo-windows-amd64.zip"
+	published := fmt.Sprintf("%x", sha256.Sum256(bin))
+	arch, err := g.downloadAndGetArchitecture(context.Background(), url, "foo-windows-amd64.zip", published, "foo.exe", g.newExecutables(map[string]struct{}{}))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if arch.Hash.String() != published {
+		t.Fatalf("expect hash %s, but %s", published, arch.Hash)
+	}
+	// The body is not read with the published hash
+	if _, ok := g.cache.Get(url); ok {
+		t.Fatal("the hash not verified is recorded in the cache")
+	}
+}
+
+func TestWarnMsi(t *testing.T) {
+	var log bytes.Buffer
+	options := NewOptions()
+	options.Log = &log
+	g := New(options)
+	assets := []*forge.Asset{{Name: "app-1.0-x64.msi"}, {Name: "app-1.0-x86.msi"}}
+	g.warnMsi(assets)
+	g.warnMsi(assets)
+	if n := strings.Count(log.String(), "Warning:"); n != 1 {
+		t.Fatalf("expect the warning once, but %d times:\n%s", n, log.String())
+	}
+}
@@ -0,0 +1,418 @@
+// Package scoop generates the manifests of Scoop from the releases
+// on GitHub, GitLab and Gitea/Forgejo.
+//
+//	g := scoop.New(scoop.NewOptions())
+//	manifest, err := g.Generate(ctx, "hymkor/make-scoop-manifest")
+//	...
+//	jsonBin, err := manifest.Format()
+package scoop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"sync"
+
+	"github.com/hymkor/make-scoop-manifest/internal/archive"
+	"github.com/hymkor/make-scoop-manifest/internal/cache"
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// ErrAssetsNotFound is returned when the release has no assets for Windows.
+var ErrAssetsNotFound = errors.New("assets not found")
+
+// Generator makes the manifests with Options. It remembers the repositories
+// and their descriptions, so it is not safe for concurrent use.
+type Generator struct {
+	options      Options
+	log          io.Writer
+	cache        *cache.Cache
+	repositories map[string]*repository
+	descriptions map[*repository]*forge.Description
+	tags         *tagRule
+	archs        *archClassifier
+	// err is the error in options reported by the methods
+	err error
+	// msiWarning prints the warning about the msi packages once
+	msiWarning sync.Once
+}
+
+// New returns Generator with a copy of options.
+func New(options *Options) *Generator {
+	g := &Generator{
+		options:      *options,
+		log:          options.Log,
+		repositories: map[string]*repository{},
+		descriptions: map[*repository]*forge.Description{},
+	}
+	g.tags, g.err = compileTagRule(options.TagRegex)
+	g.archs = newArchClassifier(map[string]string{
+		"64bit": options.Keywords64,
+		"32bit": options.Keywords32,
+		"arm64": options.KeywordsArm64,
+		arm32:   keywordsArm32,
+	})
+	if options.CacheDir != "" {
+		g.cache = cache.New(options.CacheDir)
+	}
+	if g.log == nil {
+		g.log = io.Discard
+	} else {
+		g.log = &syncWriter{w: g.log}
+	}
+	return g
+}
+
+func (g *Generator) client() *http.Client {
+	if g.options.Client == nil {
+		return http.DefaultClient
+	}
+	return g.options.Client
+}
+
+// Release is a release of the repository.
+type Release struct {
+	Tag        string
+	Prerelease bool
+	version    string
+	repo       *repository
+	release    *forge.Release
+}
+
+// Repos returns the name of the repository which the release belongs to
+func (r *Release) Repos() string {
+	return r.repo.repos
+}
+
+// Version returns the version extracted from the tag with Options.TagRegex
+func (r *Release) Version() string {
+	return r.version
+}
+
+func newRelease(repo *repository, release *forge.Release, version string) *Release {
+	return &Release{
+		Tag:        release.TagName,
+		Prerelease: release.Prerelease,
+		version:    version,
+		repo:       repo,
+		release:    release,
+	}
+}
+
+// Release returns the release specified with Options.Tag, Options.Latest
+// or Options.Prerelease. Without them, the newest release which is
+// neither a draft nor a pre-release is returned. With Options.TagRegex,
+// the releases whose tags do not match it are skipped.
+func (g *Generator) Release(ctx context.Context, repo string) (*Release, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	r, err := g.openRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	var release *forge.Release
+	switch {
+	case g.options.Tag != "":
+		release, err = r.provider.GetReleaseByTag(ctx, r.owner, r.repos, g.options.Tag, g.log)
+	case g.options.Latest:
+		release, err = r.provider.GetLatestRelease(ctx, r.owner, r.repos, g.log)
+	case g.options.Prerelease:
+		release, err = r.provider.FindRelease(ctx, r.owner, r.repos, g.log, func(r *forge.Release) bool {
+			_, ok := g.tags.version(r.TagName)
+			return !r.Draft && ok
+		})
+	default:
+		release, err = r.provider.FindRelease(ctx, r.owner, r.repos, g.log, func(r *forge.Release) bool {
+			_, ok := g.tags.version(r.TagName)
+			return !r.Draft && !r.Prerelease && ok
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	version, ok := g.tags.version(release.TagName)
+	if !ok {
+		return nil, fmt.Errorf("%s: the tag does not match %s", release.TagName, g.tags.rx.String())
+	}
+	return newRelease(r, release, version), nil
+}
+
+// Releases returns all the releases except drafts from the newest one.
+// With Options.TagRegex, the releases whose tags do not match it are skipped.
+func (g *Generator) Releases(ctx context.Context, repo string) ([]*Release, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	r, err := g.openRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := r.provider.ListReleases(ctx, r.owner, r.repos, g.log)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Release, 0, len(releases))
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		if version, ok := g.tags.version(release.TagName); ok {
+			result = append(result, newRelease(r, release, version))
+		}
+	}
+	return result, nil
+}
+
+// Generate makes the manifest for the release of the repository selected
+// as Release does. repo is written as OWNER/REPOS, a URL or git@HOST:OWNER/REPOS.git
+func (g *Generator) Generate(ctx context.Context, repo string) (*Manifest, error) {
+	release, err := g.Release(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	return g.GenerateRelease(ctx, release)
+}
+
+// getDescription returns the description of the repository.
+// It is queried only once for each repository.
+func (g *Generator) getDescription(ctx context.Context, repo *repository) (*forge.Description, error) {
+	if desc, ok := g.descriptions[repo]; ok {
+		return desc, nil
+	}
+	desc, err := repo.provider.GetDescription(ctx, repo.owner, repo.repos, g.log)
+	if err != nil {
+		return nil, err
+	}
+	g.descriptions[repo] = desc
+	return desc, nil
+}
+
+// releaseVariables returns the variables of Scoop for the release.
+// The named groups of Options.TagRegex are $matchTag and so on,
+// which "checkver" captures with the same names.
+func (g *Generator) releaseVariables(release *Release) []versionVariable {
+	return versionVariables(release.Version(), g.tags.matches(release.Tag))
+}
+
+// autoUpdateUrl returns the URL for "autoupdate" in which the version
+// in the URL of the asset is replaced with the variables of Scoop.
+func (g *Generator) autoUpdateUrl(release *Release, url string) (string, error) {
+	return templateUrl(url, release.repo.pathPrefix(), g.releaseVariables(release))
+}
+
+// GenerateRelease makes the manifest for the release from Options.Template.
+func (g *Generator) GenerateRelease(ctx context.Context, release *Release) (*Manifest, error) {
+	var manifest Manifest
+	if g.options.Template != nil {
+		if err := json.Unmarshal(g.options.Template, &manifest); err != nil {
+			return nil, err
+		}
+	}
+	repo := release.repo
+	fmt.Fprintln(g.log, "Search the assets of", release.Tag)
+
+	arch := make(map[string]*Archtecture)
+
+	var binfiles = map[string]struct{}{}
+
+	// When some assets are for the same architecture,
+	// the kind of archive which comes first in archive.Rank is used.
+	candidates := map[string]*forge.Asset{}
+	bitsOf := map[*forge.Asset]string{}
+	var unknown []*forge.Asset
+	for _, asset1 := range release.release.Assets {
+		name := asset1.Name
+		kind := archive.Kind(name)
+		if kind == "" {
+			continue
+		}
+		if !g.isWindowsAssetName(name) {
+			continue
+		}
+		var bits string
+		if !g.options.AnyCPU {
+			bits = g.getBits(name)
+			if bits == "" {
+				continue
+			}
+			if bits == archUnknown {
+				unknown = append(unknown, asset1)
+				continue
+			}
+		}
+		bitsOf[asset1] = bits
+		if prev, ok := candidates[bits]; ok {
+			if archive.Rank(kind) >= archive.Rank(archive.Kind(prev.Name)) {
+				fmt.Fprintf(g.log, "%s: ignored because %s is used\n", name, prev.Name)
+				continue
+			}
+			fmt.Fprintf(g.log, "%s: ignored because %s is used\n", prev.Name, name)
+		}
+		candidates[bits] = asset1
+	}
+	// The architectures of the assets whose names tell nothing about it
+	// are read from the headers of the executables in them, when no other
+	// assets are found or Options.PECheck is given.
+	needMachine := map[*forge.Asset]bool{}
+	if len(candidates) <= 0 || g.options.PECheck {
+		for _, asset1 := range unknown {
+			needMachine[asset1] = true
+		}
+	}
+	if len(candidates) <= 0 && len(needMachine) <= 0 {
+		return nil, fmt.Errorf("%s: %w", release.Tag, ErrAssetsNotFound)
+	}
+	exeName := repo.repos + ".exe"
+
+	var selected []*forge.Asset
+	for _, asset1 := range release.release.Assets {
+		if bits, ok := bitsOf[asset1]; (ok && candidates[bits] == asset1) || needMachine[asset1] {
+			selected = append(selected, asset1)
+		}
+	}
+	checksums := g.findChecksums(ctx, repo, release, selected)
+	// The assets are downloaded only when the files in them are needed
+	// or no hashes of them are published.
+	needInside := manifest.Bin == nil || g.options.ExtractDir
+	if manifest.Bin == nil {
+		g.warnMsi(selected)
+	}
+	results, err := g.inspectAssets(ctx, selected, checksums, needInside, needMachine, exeName)
+	if err != nil {
+		return nil, err
+	}
+	checksumOf := map[string]*checksum{}
+	usedBy := map[string]*forge.Asset{}
+	// archivedLicense is the license file in the first asset used
+	var archivedLicense []byte
+	use := func(i int, bits string) {
+		arch[bits] = results[i].arch
+		checksumOf[bits] = checksums[selected[i]]
+		usedBy[bits] = selected[i]
+		maps.Copy(binfiles, results[i].executables)
+		if archivedLicense == nil {
+			archivedLicense = results[i].license
+		}
+	}
+	for i, asset1 := range selected {
+		if needMachine[asset1] {
+			continue
+		}
+		bits := bitsOf[asset1]
+		if g.options.PECheck && !g.options.AnyCPU {
+			if err := g.checkMachines(asset1.Name, bits, results[i].machines); err != nil {
+				return nil, err
+			}
+		}
+		use(i, bits)
+	}
+	for i, asset1 := range selected {
+		if !needMachine[asset1] {
+			continue
+		}
+		switch bits := machineOf(results[i].machines); bits {
+		case "32bit", "64bit", "arm64":
+			if prev, ok := usedBy[bits]; ok {
+				fmt.Fprintf(g.log, "%s: ignored because %s is used\n", asset1.Name, prev.Name)
+				continue
+			}
+			fmt.Fprintf(g.log, "%s: %s by the headers of the executables\n", asset1.Name, bits)
+			use(i, bits)
+		case "":
+			fmt.Fprintf(g.log, "%s: ignored because the architecture of the executables is unknown\n", asset1.Name)
+		default:
+			fmt.Fprintf(g.log, "%s: ignored because the executables are for %s\n", asset1.Name, bits)
+		}
+	}
+	if len(arch) <= 0 {
+		return nil, fmt.Errorf("%s: %w", release.Tag, ErrAssetsNotFound)
+	}
+
+	autoUpdate := !g.options.NoAutoUpdate
+	if autoUpdate {
+		manifest.AutoUpdate = &AutoUpdate{}
+	}
+	if g.options.Description != "" {
+		manifest.Description = g.options.Description
+	}
+	if g.options.License != "" {
+		manifest.License = &License{Identifier: g.options.License}
+	}
+	if manifest.Bin == nil {
+		manifest.Bin = keysToSlice(binfiles)
+	}
+	// The architectures are only the ones of the release. Those of the
+	// template, which is the current manifest with -update, are not kept.
+	manifest.Archtectures = nil
+	if !g.options.AnyCPU {
+		manifest.Archtectures = make(map[string]*Archtecture)
+	}
+	if manifest.AutoUpdate != nil && manifest.AutoUpdate.Archtectures == nil {
+		manifest.AutoUpdate.Archtectures = map[string]*AutoUpdateArchtecture{}
+	}
+	if manifest.Homepage == "" {
+		manifest.Homepage = repo.provider.Homepage(repo.owner, repo.repos)
+	}
+	if g.options.AnyCPU {
+		manifest.Version = release.Version()
+		arch1 := arch[""]
+		if arch1 == nil {
+			return nil, ErrAssetsNotFound
+		}
+		manifest.UrlForAnyCPU = arch1.Url
+		manifest.HashForAnyCPU = arch1.Hash
+		if manifest.AutoUpdate != nil {
+			autoupdate, err := g.autoUpdateUrl(release, arch1.Url.String())
+			if err != nil {
+				fmt.Fprintf(g.log, "Warning: \"autoupdate\" is not written: %s\n", err.Error())
+				manifest.AutoUpdate = nil
+			} else {
+				manifest.AutoUpdate.UrlForAnyCPU = Strings{autoupdate}
+				manifest.AutoUpdate.HashForAnyCPU = checksumOf[""].extraction()
+			}
+		}
+	} else {
+		for name, val := range arch {
+			manifest.Archtectures[name] = val
+			manifest.Version = release.Version()
+			if manifest.AutoUpdate == nil {
+				continue
+			}
+			autoupdate, err := g.autoUpdateUrl(release, val.Url.String())
+			if err != nil {
+				fmt.Fprintf(g.log, "Warning: \"autoupdate\" is not written: %s\n", err.Error())
+				manifest.AutoUpdate = nil
+				continue
+			}
+			manifest.AutoUpdate.Archtectures[name] = &AutoUpdateArchtecture{
+				Url:  Strings{autoupdate},
+				Hash: checksumOf[name].extraction(),
+			}
+		}
+	}
+	// "checkver" is made only for "autoupdate". That of the template is kept.
+	if manifest.AutoUpdate != nil && manifest.CheckVer == nil {
+		manifest.CheckVer = g.checkVer(ctx, release)
+	}
+	if desc, err := g.getDescription(ctx, repo); err == nil {
+		if manifest.Description == "" {
+			description := desc.Description
+			if description == "" {
+				fmt.Fprintf(g.log, "Warning: \"description\" field on %s is empty\n", repo.provider.Name())
+			}
+			manifest.Description = description
+		}
+		if manifest.License == nil || manifest.License.Identifier == "" {
+			manifest.License = licenseOf(desc, archivedLicense)
+			if manifest.License == nil {
+				fmt.Fprintf(g.log, "Warning: \"license\" field on %s is empty\n", repo.provider.Name())
+			}
+		}
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,115 @@
+package scoop
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// hashTemplates are the variables which Scoop replaces with
+// the patterns in "regex" of "hash"
+var hashTemplates = map[string]string{
+	"$md5":      `([a-fA-F0-9]{32})`,
+	"$sha1":     `([a-fA-F0-9]{40})`,
+	"$sha256":   `([a-fA-F0-9]{64})`,
+	"$sha512":   `([a-fA-F0-9]{128})`,
+	"$checksum": `([a-fA-F0-9]{32,128})`,
+	"$base64":   `([a-zA-Z0-9+\/=]{24,88})`,
+}
+
+// substitute replaces the variables in s with values.
+// The longer names are replaced first not to break $sha256 with $sha2 and so on.
+func substitute(s string, values map[string]string, quote bool) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, name := range names {
+		value := values[name]
+		if quote {
+			value = regexp.QuoteMeta(value)
+		}
+		s = strings.ReplaceAll(s, name, value)
+	}
+	return s
+}
+
+// urlVariables returns the variables which Scoop replaces
+// in "hash" of "autoupdate" for the asset URL.
+func urlVariables(assetUrl, version string) map[string]string {
+	assetUrl, _, _ = strings.Cut(assetUrl, "#")
+	basename := path.Base(assetUrl)
+	if u, err := url.Parse(assetUrl); err == nil {
+		basename = path.Base(u.Path)
+	}
+	return map[string]string{
+		"$version":  version,
+		"$url":      assetUrl,
+		"$basename": basename,
+		"$baseurl":  strings.TrimSuffix(assetUrl, "/"+basename),
+	}
+}
+
+// errNotVerified is returned by Find for the modes it does not emulate
+var errNotVerified = errors.New("not verified")
+
+// Find returns the hash which Scoop finds in text (the checksum file)
+// for the asset URL of the version. Only the mode "extract" is supported.
+func (h *HashExtraction) Find(text []byte, assetUrl, version string) (string, error) {
+	if h.JsonPath != "" || (h.Mode != "" && h.Mode != "extract") {
+		return "", errNotVerified
+	}
+	values := urlVariables(assetUrl, version)
+	for _, v := range versionVariables(version, nil) {
+		values[v.name] = v.value
+	}
+	regex := h.Regex
+	if regex == "" {
+		regex = `^\s*([a-fA-F0-9]+)\s*$`
+	}
+	regex = substitute(regex, hashTemplates, false)
+	regex = substitute(regex, values, true)
+	// PowerShell's -match ignores the case.
+	rx, err := regexp.Compile("(?i)" + regex)
+	if err != nil {
+		return "", err
+	}
+	if m := rx.FindSubmatch(text); m != nil && len(m) >= 2 {
+		return strings.ToLower(string(m[1])), nil
+	}
+	if h.Regex != "" {
+		return "", fmt.Errorf("%s: the hash is not found with %q", h.Url, h.Regex)
+	}
+	// Scoop finds the line with the file name when no patterns are given
+	basename := regexp.QuoteMeta(values["$basename"])
+	rx = regexp.MustCompile(`(?i)([a-fA-F0-9]{32,128})[\x20\t]+.*` + basename + `(?:\s|$)|` + basename + `[\x20\t]+.*?([a-fA-F0-9]{32,128})`)
+	if m := rx.FindSubmatch(text); m != nil {
+		return strings.ToLower(string(m[1]) + string(m[2])), nil
+	}
+	return "", fmt.Errorf("%s: the hash of %s is not found", h.Url, values["$basename"])
+}
+
+// verify checks that Scoop finds the hash in the checksum file text
+// downloaded from fileUrl for the asset URL of the version.
+// vars are the variables of the version for the URL.
+func (h *HashExtraction) verify(text []byte, fileUrl, assetUrl, version, hash string, vars []versionVariable) error {
+	if u := expandVersion(h.Url, vars); u != fileUrl {
+		return fmt.Errorf("%s: the URL becomes %s with $version=%s", h.Url, u, version)
+	}
+	found, err := h.Find(text, assetUrl, version)
+	if errors.Is(err, errNotVerified) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if found != hash {
+		return fmt.Errorf("%s: %s is found instead of %s", h.Url, found, hash)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package scoop
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashExtractionFind(t *testing.T) {
+	hash1 := strings.Repeat("1", 64)
+	hash2 := strings.Repeat("2", 64)
+	const assetUrl = "https://example.com/foo/releases/download/v1.0/foo-1.0-windows-amd64.zip"
+	for _, tc := range []struct {
+		text   string
+		regex  string
+		expect string
+	}{
+		{hash1 + "\n", "", hash1},
+		{hash2 + "  foo-1.0-windows-386.zip\n" + hash1 + "  foo-1.0-windows-amd64.zip\n", "", hash1},
+		{hash2 + "  foo-1.0-windows-386.zip\n" + hash1 + " *foo-1.0-windows-amd64.zip\n", `$sha256\s+\*?$basename`, hash1},
+		{hash1 + "  dist/foo-1.0-windows-amd64.zip\n", `$sha256\s+\S*/$basename`, hash1},
+		{"SHA256 (foo-1.0-windows-amd64.zip) = " + hash1 + "\n", `\(\S*$basename\)\s*=\s*$sha256`, hash1},
+		{hash1 + "  foo-1.0-windows-386.zip\n", `$sha256\s+\*?$basename`, ""},
+	} {
+		h := &HashExtraction{Url: "https://example.com/checksums.txt", Regex: tc.regex}
+		result, err := h.Find([]byte(tc.text), assetUrl, "1.0")
+		if tc.expect == "" {
+			if err == nil {
+				t.Fatalf("%q: expect an error, but %s", tc.text, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %s", tc.text, err.Error())
+		}
+		if result != tc.expect {
+			t.Fatalf("%q: expect %s, but %s", tc.text, tc.expect, result)
+		}
+	}
+}
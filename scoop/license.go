@@ -0,0 +1,147 @@
+package scoop
+
+import (
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/archive"
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// licenseTextLimit is the maximum size of the license file read
+const licenseTextLimit = 64 * 1024
+
+// rxLicenseFile matches the names of the license files in the archives
+var rxLicenseFile = regexp.MustCompile(`(?i)^(licen[cs]e|copying)(-[a-z0-9]+)?(\.(txt|md|rst))?$`)
+
+// isLicenseFile reports whether the file in the archive is the license
+// of the application: LICENSE, LICENSE.txt, COPYING and so on at the top
+// or in the directory of the application.
+func isLicenseFile(name string) bool {
+	return strings.Count(strings.Trim(name, "/"), "/") <= 1 && rxLicenseFile.MatchString(path.Base(name))
+}
+
+// readLicense reads the license file in the archive
+func readLicense(open archive.Opener) ([]byte, error) {
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, licenseTextLimit))
+}
+
+// licenseRule tells the SPDX identifier of the license whose text
+// contains all the phrases.
+type licenseRule struct {
+	id      string
+	phrases []string
+}
+
+// licenseRules are tried in order. The phrases are in lower case
+// and the spaces in them are single.
+//
+// The GNU licenses are told by their title lines because their texts
+// mention each other. Whether "-only" or "-or-later" is told only by
+// the notices in the source files, so the identifiers without them
+// are used as GitHub does.
+var licenseRules = []licenseRule{
+	{id: "AGPL-3.0", phrases: []string{"gnu affero general public license version 3, 19 november 2007"}},
+	{id: "LGPL-3.0", phrases: []string{"gnu lesser general public license version 3, 29 june 2007"}},
+	{id: "LGPL-2.1", phrases: []string{"gnu lesser general public license version 2.1, february 1999"}},
+	{id: "GPL-3.0", phrases: []string{"gnu general public license version 3, 29 june 2007"}},
+	{id: "GPL-2.0", phrases: []string{"gnu general public license version 2, june 1991"}},
+	{id: "Apache-2.0", phrases: []string{"apache license", "version 2.0"}},
+	{id: "MPL-2.0", phrases: []string{"mozilla public license", "2.0"}},
+	{id: "EPL-2.0", phrases: []string{"eclipse public license - v 2.0"}},
+	{id: "BSL-1.0", phrases: []string{"boost software license - version 1.0"}},
+	{id: "CC0-1.0", phrases: []string{"cc0 1.0 universal"}},
+	{id: "Unlicense", phrases: []string{"this is free and unencumbered software released into the public domain"}},
+	{id: "WTFPL", phrases: []string{"do what the fuck you want to public license"}},
+	{id: "MIT", phrases: []string{
+		"permission is hereby granted, free of charge, to any person obtaining a copy",
+		"the above copyright notice and this permission notice shall be included",
+	}},
+	{id: "MIT-0", phrases: []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{id: "ISC", phrases: []string{
+		"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted",
+		"provided that the above copyright notice and this permission notice appear in all copies",
+	}},
+	{id: "0BSD", phrases: []string{"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted"}},
+	{id: "BSD-4-Clause", phrases: []string{"redistribution and use in source and binary forms", "all advertising materials"}},
+	{id: "BSD-3-Clause", phrases: []string{"redistribution and use in source and binary forms", "may be used to endorse or promote products"}},
+	{id: "BSD-2-Clause", phrases: []string{"redistribution and use in source and binary forms"}},
+	{id: "Zlib", phrases: []string{"altered source versions must be plainly marked"}},
+}
+
+// normalizeText makes the text lower case and its spaces single
+func normalizeText(text []byte) string {
+	return strings.Join(strings.Fields(strings.ToLower(string(text))), " ")
+}
+
+// detectLicense returns the SPDX identifier of the license text,
+// or "" when it is not a well-known license.
+func detectLicense(text []byte) string {
+	normalized := normalizeText(text)
+	if normalized == "" {
+		return ""
+	}
+	for _, rule := range licenseRules {
+		matched := true
+		for _, phrase := range rule.phrases {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule.id
+		}
+	}
+	return ""
+}
+
+// spdxOfForge returns the SPDX identifier of the license the forge tells
+func spdxOfForge(desc *forge.Description) (string, bool) {
+	if id, ok := spdxOf(desc.License); ok {
+		return id, true
+	}
+	if id, ok := canonicalSpdx(desc.License); ok {
+		return id, true
+	}
+	if id := detectLicense(desc.LicenseText); id != "" {
+		return id, true
+	}
+	return "", false
+}
+
+// licenseOf returns "license" of the manifest. The SPDX identifier is
+// taken from the forge, the license file of the repository, or the one
+// in the archive in this order. For the other licenses, the object form
+// with the URL of the license file is returned. It is nil when nothing
+// is known about the license.
+func licenseOf(desc *forge.Description, archived []byte) *License {
+	if id, ok := spdxOfForge(desc); ok {
+		return &License{Identifier: id}
+	}
+	if id := detectLicense(archived); id != "" {
+		return &License{Identifier: id}
+	}
+	text := desc.LicenseText
+	if len(text) == 0 {
+		text = archived
+	}
+	if desc.LicenseUrl == "" && len(text) == 0 {
+		if desc.License == "" {
+			return nil
+		}
+		return &License{Identifier: desc.License}
+	}
+	identifier := "Unknown"
+	if strings.Contains(normalizeText(text), "all rights reserved") {
+		identifier = "Proprietary"
+	}
+	return &License{Identifier: identifier, Url: desc.LicenseUrl}
+}
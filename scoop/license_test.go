@@ -0,0 +1,129 @@
+package scoop
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+)
+
+// licenseText returns the full text of the license in testdata/license
+func licenseText(t *testing.T, id string) []byte {
+	t.Helper()
+	text, err := os.ReadFile(filepath.Join("testdata", "license", id+".txt"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return text
+}
+
+func TestDetectLicense(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "license", "*.txt"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(files) == 0 {
+		t.Fatal("no license texts in testdata/license")
+	}
+	// The name of the file is the identifier expected
+	for _, fname := range files {
+		expect := strings.TrimSuffix(filepath.Base(fname), ".txt")
+		if result := detectLicense(licenseText(t, expect)); result != expect {
+			t.Errorf("%s: expect %q, but %q", fname, expect, result)
+		}
+	}
+	for _, tc := range []struct {
+		text   string
+		expect string
+	}{
+		{"This is free and unencumbered software released into the public domain.", "Unlicense"},
+		{"Copyright (c) 2024 o\nAll rights reserved.", ""},
+		{"", ""},
+	} {
+		if result := detectLicense([]byte(tc.text)); result != tc.expect {
+			t.Errorf("%.30q: expect %q, but %q", tc.text, tc.expect, result)
+		}
+	}
+}
+
+func TestLicenseOf(t *testing.T) {
+	const licenseUrl = "https://github.com/o/r/blob/main/LICENSE"
+	for _, tc := range []struct {
+		name     string
+		desc     forge.Description
+		archived []byte
+		expect   License
+	}{
+		{
+			name:   "spdx",
+			desc:   forge.Description{License: "MIT"},
+			expect: License{Identifier: "MIT"},
+		},
+		{
+			name:   "name",
+			desc:   forge.Description{License: "Apache License 2.0"},
+			expect: License{Identifier: "Apache-2.0"},
+		},
+		{
+			name:   "repository",
+			desc:   forge.Description{License: "Other", LicenseUrl: licenseUrl, LicenseText: licenseText(t, "BSD-3-Clause")},
+			expect: License{Identifier: "BSD-3-Clause"},
+		},
+		{
+			name:     "archive",
+			desc:     forge.Description{},
+			archived: licenseText(t, "MIT"),
+			expect:   License{Identifier: "MIT"},
+		},
+		{
+			name:   "proprietary",
+			desc:   forge.Description{License: "Other", LicenseUrl: licenseUrl, LicenseText: []byte("Copyright (c) 2024 o\nAll Rights Reserved.")},
+			expect: License{Identifier: "Proprietary", Url: licenseUrl},
+		},
+		{
+			name:   "unknown",
+			desc:   forge.Description{License: "Other", LicenseUrl: licenseUrl, LicenseText: []byte("You may use it only on Sundays.")},
+			expect: License{Identifier: "Unknown", Url: licenseUrl},
+		},
+		{
+			name:     "unknown in archive",
+			desc:     forge.Description{},
+			archived: []byte("Copyright (c) 2024 o\nAll rights reserved."),
+			expect:   License{Identifier: "Proprietary"},
+		},
+		{
+			name:   "none",
+			desc:   forge.Description{},
+			expect: License{},
+		},
+	} {
+		var result License
+		if l := licenseOf(&tc.desc, tc.archived); l != nil {
+			result = *l
+		}
+		if result != tc.expect {
+			t.Errorf("%s: expect %+v, but %+v", tc.name, tc.expect, result)
+		}
+	}
+}
+
+func TestIsLicenseFile(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		expect bool
+	}{
+		{"LICENSE", true},
+		{"LICENSE.txt", true},
+		{"app-1.0/License.md", true},
+		{"COPYING", true},
+		{"licenses/third_party/LICENSE", false},
+		{"license-checker.exe", false},
+		{"README.md", false},
+	} {
+		if result := isLicenseFile(tc.name); result != tc.expect {
+			t.Errorf("%q: expect %v, but %v", tc.name, tc.expect, result)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package scoop
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/archive"
+)
+
+// The severities of LintRule
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// LintRule is a rule of the manifests which Lint checks
+type LintRule struct {
+	ID          string
+	Severity    string
+	Description string
+}
+
+var (
+	RuleLicenseSpdx = &LintRule{
+		ID:          "license-spdx",
+		Severity:    SeverityWarning,
+		Description: "\"license\" is an SPDX license identifier",
+	}
+	RuleHttpsUrl = &LintRule{
+		ID:          "https-url",
+		Severity:    SeverityWarning,
+		Description: "The URLs use https instead of http",
+	}
+	RuleAutoUpdateVersion = &LintRule{
+		ID:          "autoupdate-version",
+		Severity:    SeverityError,
+		Description: "The URLs of \"autoupdate\" have the variables of the version like $version",
+	}
+	RuleBinInArchive = &LintRule{
+		ID:          "bin-in-archive",
+		Severity:    SeverityError,
+		Description: "The files of \"bin\" are in the archives",
+	}
+	RuleCheckVerMissing = &LintRule{
+		ID:          "checkver-missing",
+		Severity:    SeverityError,
+		Description: "\"checkver\" is given with \"autoupdate\"",
+	}
+)
+
+// LintRules are all the rules Lint checks
+var LintRules = []*LintRule{
+	RuleLicenseSpdx,
+	RuleHttpsUrl,
+	RuleAutoUpdateVersion,
+	RuleBinInArchive,
+	RuleCheckVerMissing,
+}
+
+// Problem is what Lint found in the manifest
+type Problem struct {
+	Rule *LintRule
+	// Path is the JSON pointer to the value like "/architecture/64bit/url"
+	Path    string
+	Message string
+	// Fixed is true when the problem is fixed in the manifest
+	Fixed bool
+}
+
+func (p *Problem) String() string {
+	severity := p.Rule.Severity
+	if p.Fixed {
+		severity = "fixed"
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", severity, cmp.Or(p.Path, "(root)"), p.Message, p.Rule.ID)
+}
+
+// linter keeps the state of Lint for a manifest
+type linter struct {
+	g        *Generator
+	m        *Manifest
+	fix      bool
+	problems []*Problem
+}
+
+func (l *linter) report(rule *LintRule, path string, fixed bool, format string, args ...any) {
+	l.problems = append(l.problems, &Problem{
+		Rule:    rule,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+		Fixed:   fixed,
+	})
+}
+
+// Lint checks the manifest with LintRules. With fix, the problems which
+// can be fixed are fixed in m and returned with Problem.Fixed.
+// The archives are downloaded to list the files in them unless they are
+// in the cache.
+func (g *Generator) Lint(ctx context.Context, m *Manifest, fix bool) ([]*Problem, error) {
+	l := &linter{g: g, m: m, fix: fix}
+	l.license()
+	l.httpsUrls()
+	l.autoUpdateVersion()
+	if err := l.binInArchive(ctx); err != nil {
+		return nil, err
+	}
+	l.checkVerMissing()
+	return l.problems, nil
+}
+
+func (l *linter) license() {
+	if l.m.License == nil {
+		return
+	}
+	id := l.m.License.Identifier
+	path := "/license"
+	if l.m.License.Url != "" {
+		path = "/license/identifier"
+	}
+	if id == "" || isSpdxExpression(id) || slices.Contains(scoopLicenses, id) || strings.Contains(id, "://") {
+		return
+	}
+	spdx, ok := spdxOf(id)
+	if !ok {
+		spdx, ok = canonicalSpdx(id)
+	}
+	switch {
+	case !ok:
+		l.report(RuleLicenseSpdx, path, false, "%q is not an SPDX license identifier", id)
+	case l.fix:
+		l.m.License.Identifier = spdx
+		l.report(RuleLicenseSpdx, path, true, "%q is replaced with %q", id, spdx)
+	default:
+		l.report(RuleLicenseSpdx, path, false, "%q is not an SPDX license identifier. Use %q", id, spdx)
+	}
+}
+
+// https reports the URL *u which starts with http://
+func (l *linter) https(path string, u *string) {
+	rest, ok := strings.CutPrefix(*u, "http://")
+	if !ok {
+		return
+	}
+	if l.fix {
+		*u = "https://" + rest
+		l.report(RuleHttpsUrl, path, true, "http:// is replaced with https://")
+		return
+	}
+	l.report(RuleHttpsUrl, path, false, "%s does not use https", *u)
+}
+
+// elementPath returns the JSON pointer to s[i], which is path itself
+// when s is written as a string.
+func elementPath(path string, s Strings, i int) string {
+	if len(s) == 1 {
+		return path
+	}
+	return fmt.Sprintf("%s/%d", path, i)
+}
+
+// httpsAll reports the URLs of s which start with http://
+func (l *linter) httpsAll(path string, s Strings) {
+	for i := range s {
+		l.https(elementPath(path, s, i), &s[i])
+	}
+}
+
+func (l *linter) httpsUrls() {
+	m := l.m
+	l.https("/homepage", &m.Homepage)
+	if m.License != nil {
+		l.https("/license/url", &m.License.Url)
+	}
+	l.httpsAll("/url", m.UrlForAnyCPU)
+	for _, name := range slices.Sorted(maps.Keys(m.Archtectures)) {
+		l.httpsAll("/architecture/"+name+"/url", m.Archtectures[name].Url)
+	}
+	if checkver, ok := m.CheckVer.(map[string]any); ok {
+		for _, key := range []string{"github", "url"} {
+			if u, ok := checkver[key].(string); ok {
+				l.https("/checkver/"+key, &u)
+				checkver[key] = u
+			}
+		}
+	}
+	if m.AutoUpdate == nil {
+		return
+	}
+	l.httpsAll("/autoupdate/url", m.AutoUpdate.UrlForAnyCPU)
+	l.hashUrls("/autoupdate/hash", m.AutoUpdate.HashForAnyCPU)
+	for _, name := range slices.Sorted(maps.Keys(m.AutoUpdate.Archtectures)) {
+		a := m.AutoUpdate.Archtectures[name]
+		l.httpsAll("/autoupdate/architecture/"+name+"/url", a.Url)
+		l.hashUrls("/autoupdate/architecture/"+name+"/hash", a.Hash)
+	}
+}
+
+// hashUrls reports the URLs of "hash" of "autoupdate" which start with http://
+func (l *linter) hashUrls(path string, h HashExtractions) {
+	for i, e := range h {
+		if e == nil {
+			continue
+		}
+		p := path + "/url"
+		if len(h) != 1 {
+			p = fmt.Sprintf("%s/%d/url", path, i)
+		}
+		l.https(p, &e.Url)
+	}
+}
+
+// rxVersionVariable matches the variables of Scoop which have the version
+var rxVersionVariable = regexp.MustCompile(`\$(version|dotVersion|underscoreVersion|dashVersion|cleanVersion|majorVersion|minorVersion|patchVersion|buildVersion|preReleaseVersion|match[A-Z]\w*)`)
+
+// pathPrefixOf returns the path of the homepage like "/OWNER/REPOS/"
+// when assetUrl is on the same host. The version in it is not replaced.
+func pathPrefixOf(homepage, assetUrl string) string {
+	h, err := url.Parse(homepage)
+	if err != nil {
+		return ""
+	}
+	a, err := url.Parse(assetUrl)
+	if err != nil || a.Host != h.Host {
+		return ""
+	}
+	return strings.TrimSuffix(h.Path, "/") + "/"
+}
+
+// autoUpdateUrl checks the URL *u of "autoupdate". current is the URL of
+// the current version, which is used to fix it.
+func (l *linter) autoUpdateUrl(path string, u *string, current string) {
+	if *u == "" || rxVersionVariable.MatchString(*u) {
+		return
+	}
+	if l.fix && current != "" {
+		prefix := pathPrefixOf(l.m.Homepage, current)
+		if fixed, err := templateUrl(current, prefix, versionVariables(l.m.Version, nil)); err == nil {
+			*u = fixed
+			l.report(RuleAutoUpdateVersion, path, true, "replaced with %s", fixed)
+			return
+		}
+	}
+	l.report(RuleAutoUpdateVersion, path, false, "%s has no variables of the version like $version", *u)
+}
+
+func (l *linter) autoUpdateVersion() {
+	m := l.m
+	if m.AutoUpdate == nil {
+		return
+	}
+	l.autoUpdateUrls("/autoupdate/url", m.AutoUpdate.UrlForAnyCPU, m.UrlForAnyCPU)
+	for _, name := range slices.Sorted(maps.Keys(m.AutoUpdate.Archtectures)) {
+		var current Strings
+		if a := m.Archtectures[name]; a != nil {
+			current = a.Url
+		}
+		l.autoUpdateUrls("/autoupdate/architecture/"+name+"/url", m.AutoUpdate.Archtectures[name].Url, current)
+	}
+}
+
+// autoUpdateUrls checks the URLs of "autoupdate" with those of the
+// current version at the same index. The files after the first one are
+// checked only when their current URLs have the version, because the
+// files installed together may not change with the version.
+func (l *linter) autoUpdateUrls(path string, urls, current Strings) {
+	for i := range urls {
+		var c string
+		if i < len(current) {
+			c = current[i]
+		}
+		if i > 0 && (l.m.Version == "" || !strings.Contains(c, l.m.Version)) {
+			continue
+		}
+		l.autoUpdateUrl(elementPath(path, urls, i), &urls[i], c)
+	}
+}
+
+// binEntry is a file of "bin" and the JSON pointer to it
+type binEntry struct {
+	file string
+	path string
+}
+
+// binEntries returns the files of "bin": a string, an array of strings,
+// or an array of the arrays whose first elements are the files.
+func binEntries(bin any) []binEntry {
+	var result []binEntry
+	switch v := bin.(type) {
+	case string:
+		result = append(result, binEntry{v, "/bin"})
+	case []string:
+		for i, s := range v {
+			result = append(result, binEntry{s, fmt.Sprintf("/bin/%d", i)})
+		}
+	case []any:
+		for i, e := range v {
+			switch e := e.(type) {
+			case string:
+				result = append(result, binEntry{e, fmt.Sprintf("/bin/%d", i)})
+			case []any:
+				if len(e) > 0 {
+					if s, ok := e[0].(string); ok {
+						result = append(result, binEntry{s, fmt.Sprintf("/bin/%d/0", i)})
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// listFiles returns the files in the asset of rawUrl. The name after "#/"
+// is the name of the file Scoop saves. ok is false when the files in it
+// can not be listed.
+func (g *Generator) listFiles(ctx context.Context, rawUrl string) (files []string, ok bool, err error) {
+	u, fragment, _ := strings.Cut(rawUrl, "#")
+	name := path.Base(strings.SplitN(u, "?", 2)[0])
+	if rename, ok := strings.CutPrefix(fragment, "/"); ok {
+		name = rename
+	}
+	if kind := archive.Kind(name); kind == "" || kind == ".exe" {
+		return []string{name}, true, nil
+	}
+	if !g.canList(name) {
+		return nil, false, nil
+	}
+	e := g.newExecutables(map[string]struct{}{})
+	e.peCheck = false
+	fmt.Fprintln(g.log, "Download:", u)
+	if _, err := g.downloadAndGetArchitecture(ctx, u, name, "", name, e); err != nil {
+		return nil, false, err
+	}
+	for i, f := range e.files {
+		e.files[i] = path.Clean(f)
+	}
+	return e.files, true, nil
+}
+
+func (l *linter) binInArchive(ctx context.Context) error {
+	bins := binEntries(l.m.Bin)
+	if len(bins) == 0 {
+		return nil
+	}
+	// The files of "url" are extracted with "extract_dir" at the same index
+	type target struct {
+		arch        string
+		urls        Strings
+		extractDirs Strings
+	}
+	var targets []target
+	if len(l.m.UrlForAnyCPU) > 0 {
+		var top struct {
+			ExtractDir Strings `json:"extract_dir"`
+		}
+		json.Unmarshal(l.m.source, &top)
+		targets = append(targets, target{"", l.m.UrlForAnyCPU, top.ExtractDir})
+	}
+	for _, name := range slices.Sorted(maps.Keys(l.m.Archtectures)) {
+		a := l.m.Archtectures[name]
+		targets = append(targets, target{name, a.Url, a.ExtractDir})
+	}
+	for _, t := range targets {
+		listed, ok, err := l.listAll(ctx, t.urls)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		for _, b := range bins {
+			if strings.Contains(b.file, "$") {
+				continue
+			}
+			bin := strings.ReplaceAll(b.file, `\`, "/")
+			inside := func(i int) string {
+				if i < len(t.extractDirs) {
+					return path.Join(strings.ReplaceAll(t.extractDirs[i], `\`, "/"), bin)
+				}
+				return path.Join(bin)
+			}
+			found := false
+			for i, files := range listed {
+				file := inside(i)
+				if slices.ContainsFunc(files, func(f string) bool { return strings.EqualFold(f, file) }) {
+					found = true
+					break
+				}
+			}
+			if found {
+				continue
+			}
+			where := "the archive"
+			if len(t.urls) > 1 {
+				where = "the files of \"url\""
+			}
+			if t.arch != "" {
+				where += " for " + t.arch
+			}
+			l.report(RuleBinInArchive, b.path, false, "%s is not in %s", inside(0), where)
+		}
+	}
+	return nil
+}
+
+// listAll returns the files in each of urls. ok is false when some of
+// them can not be listed.
+func (l *linter) listAll(ctx context.Context, urls Strings) (listed [][]string, ok bool, err error) {
+	for _, u := range urls {
+		files, ok, err := l.g.listFiles(ctx, u)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		listed = append(listed, files)
+	}
+	return listed, true, nil
+}
+
+func (l *linter) checkVerMissing() {
+	if l.m.AutoUpdate == nil || l.m.CheckVer != nil {
+		return
+	}
+	if h, err := url.Parse(l.m.Homepage); l.fix && err == nil && h.Host == "github.com" && strings.Count(strings.Trim(h.Path, "/"), "/") == 1 {
+		l.m.CheckVer = "github"
+		l.report(RuleCheckVerMissing, "/checkver", true, "\"github\" is given")
+		return
+	}
+	l.report(RuleCheckVerMissing, "/checkver", false, "\"checkver\" is not given though \"autoupdate\" is")
+}
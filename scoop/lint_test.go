@@ -0,0 +1,252 @@
+package scoop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	zip := makeTestZip(t, "app.exe", "README.md")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(zip)
+	}))
+	defer server.Close()
+
+	assetUrl := server.URL + "/o/app/releases/download/v1.0.0/app-1.0.0-windows-amd64.zip"
+	source := `{
+    "version": "1.0.0",
+    "description": "app",
+    "homepage": "http://github.com/o/app",
+    "license": "MIT License",
+    "notes": "kept",
+    "architecture": {
+        "64bit": {
+            "url": "` + assetUrl + `",
+            "hash": "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+        }
+    },
+    "bin": ["app.exe", "missing.exe"],
+    "autoupdate": {
+        "architecture": {
+            "64bit": {
+                "url": "` + assetUrl + `"
+            }
+        }
+    }
+}`
+	type result struct {
+		rule  string
+		path  string
+		fixed bool
+	}
+	for _, fix := range []bool{false, true} {
+		options := NewOptions()
+		options.CacheDir = ""
+		options.Client = server.Client()
+		g := New(options)
+
+		var m Manifest
+		if err := json.Unmarshal([]byte(source), &m); err != nil {
+			t.Fatal(err.Error())
+		}
+		problems, err := g.Lint(context.Background(), &m, fix)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		expect := []result{
+			{"license-spdx", "/license", fix},
+			{"https-url", "/homepage", fix},
+			{"autoupdate-version", "/autoupdate/architecture/64bit/url", fix},
+			{"bin-in-archive", "/bin/1", false},
+			{"checkver-missing", "/checkver", fix},
+		}
+		if len(problems) != len(expect) {
+			t.Fatalf("fix=%v: expect %d problems, but %v", fix, len(expect), problems)
+		}
+		for i, p := range problems {
+			if r := (result{p.Rule.ID, p.Path, p.Fixed}); r != expect[i] {
+				t.Errorf("fix=%v: expect %v, but %s", fix, expect[i], p.String())
+			}
+		}
+		if !fix {
+			continue
+		}
+		jsonBin, err := m.Format()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, s := range []string{
+			`"license": "MIT"`,
+			`"homepage": "https://github.com/o/app"`,
+			`"notes": "kept"`,
+			`/v$version/app-$version-windows-amd64.zip"`,
+			`"checkver": "github"`,
+		} {
+			if !strings.Contains(string(jsonBin), s) {
+				t.Errorf("%s is not found in\n%s", s, jsonBin)
+			}
+		}
+	}
+}
+
+func TestLintLicenseCase(t *testing.T) {
+	for _, tc := range []struct {
+		license string
+		expect  string
+	}{
+		{"mit", "MIT"},
+		{"mit or apache-2.0", "MIT OR Apache-2.0"},
+	} {
+		for _, fix := range []bool{false, true} {
+			m := &Manifest{Version: "1.0.0", License: &License{Identifier: tc.license}}
+			problems, err := New(NewOptions()).Lint(context.Background(), m, fix)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if len(problems) != 1 || problems[0].Rule.ID != "license-spdx" || problems[0].Fixed != fix {
+				t.Fatalf("%q fix=%v: expect license-spdx, but %v", tc.license, fix, problems)
+			}
+			expect := tc.license
+			if fix {
+				expect = tc.expect
+			}
+			if m.License.Identifier != expect {
+				t.Errorf("%q fix=%v: expect %q, but %q", tc.license, fix, expect, m.License.Identifier)
+			}
+		}
+	}
+}
+
+func TestIsSpdxExpression(t *testing.T) {
+	for _, tc := range []struct {
+		s      string
+		expect bool
+	}{
+		{"MIT", true},
+		{"mit", false},
+		{"MIT or Apache-2.0", false},
+		{"GPL-2.0-or-later WITH Classpath-exception-2.0", true},
+		{"(MIT OR Apache-2.0)", true},
+		{"GPL-2.0+", true},
+		{"LicenseRef-Custom", true},
+		{"MIT License", false},
+		{"MIT OR", false},
+		{"Other", false},
+	} {
+		if result := isSpdxExpression(tc.s); result != tc.expect {
+			t.Errorf("%q: expect %v, but %v", tc.s, tc.expect, result)
+		}
+	}
+}
+
+func TestCanonicalSpdx(t *testing.T) {
+	for _, tc := range []struct {
+		s      string
+		expect string
+		ok     bool
+	}{
+		{"MIT", "MIT", true},
+		{"mit", "MIT", true},
+		{"(mit or apache-2.0)", "(MIT OR Apache-2.0)", true},
+		{"gpl-2.0+ with Classpath-exception-2.0", "GPL-2.0+ WITH Classpath-exception-2.0", true},
+		{"LicenseRef-Custom and isc", "LicenseRef-Custom AND ISC", true},
+		{"MIT License", "MIT License", false},
+	} {
+		result, ok := canonicalSpdx(tc.s)
+		if result != tc.expect || ok != tc.ok {
+			t.Errorf("%q: expect %q %v, but %q %v", tc.s, tc.expect, tc.ok, result, ok)
+		}
+	}
+}
+
+func TestLintArrays(t *testing.T) {
+	// The script installed together is not in the archive
+	zip := makeTestZip(t, "app-1.0.0/app.exe")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(zip)
+	}))
+	defer server.Close()
+
+	assetUrl := server.URL + "/o/app/releases/download/v1.0.0/app-1.0.0-windows-amd64.zip"
+	source := `{
+    "version": "1.0.0",
+    "description": "app",
+    "homepage": "https://github.com/o/app",
+    "license": "MIT",
+    "url": [
+        "` + assetUrl + `",
+        "http://example.com/helper.ps1"
+    ],
+    "hash": [
+        "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+        "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+    ],
+    "extract_dir": "app-1.0.0",
+    "bin": ["app.exe", "helper.ps1", "missing.exe"],
+    "checkver": "github",
+    "autoupdate": {
+        "url": [
+            "https://github.com/o/app/releases/download/v$version/app-$version-windows-amd64.zip",
+            "http://example.com/helper.ps1"
+        ],
+        "hash": [
+            {"url": "https://github.com/o/app/releases/download/v$version/checksums.txt"},
+            {"url": "http://example.com/helper.ps1.sha256"}
+        ]
+    }
+}`
+	if err := Validate([]byte(source)); err != nil {
+		t.Fatal(err.Error())
+	}
+	type result struct {
+		rule  string
+		path  string
+		fixed bool
+	}
+	for _, fix := range []bool{false, true} {
+		options := NewOptions()
+		options.CacheDir = ""
+		options.Client = server.Client()
+		g := New(options)
+
+		var m Manifest
+		if err := json.Unmarshal([]byte(source), &m); err != nil {
+			t.Fatal(err.Error())
+		}
+		problems, err := g.Lint(context.Background(), &m, fix)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		expect := []result{
+			{"https-url", "/url/1", fix},
+			{"https-url", "/autoupdate/url/1", fix},
+			{"https-url", "/autoupdate/hash/1/url", fix},
+			{"bin-in-archive", "/bin/2", false},
+		}
+		if len(problems) != len(expect) {
+			t.Fatalf("fix=%v: expect %d problems, but %v", fix, len(expect), problems)
+		}
+		for i, p := range problems {
+			if r := (result{p.Rule.ID, p.Path, p.Fixed}); r != expect[i] {
+				t.Errorf("fix=%v: expect %v, but %s", fix, expect[i], p.String())
+			}
+		}
+		if !fix {
+			continue
+		}
+		jsonBin, err := m.Format()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := Validate(jsonBin); err != nil {
+			t.Fatal(err.Error())
+		}
+		if strings.Contains(string(jsonBin), "http://") {
+			t.Errorf("http:// is not replaced in\n%s", jsonBin)
+		}
+	}
+}
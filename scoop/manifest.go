@@ -0,0 +1,242 @@
+package scoop
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/hymkor/make-scoop-manifest/internal/ordered"
+)
+
+// Strings is "url", "hash" and "extract_dir" of the manifest. They are
+// a string, or an array of the strings for the files installed together.
+type Strings []string
+
+func (s Strings) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return ordered.Marshal(s[0])
+	}
+	return ordered.Marshal([]string(s))
+}
+
+func (s *Strings) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*s = Strings{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+// String returns the first one, which is the file of the release
+func (s Strings) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
+
+// stringsOf returns Strings of s, or nil when s is empty
+func stringsOf(s string) Strings {
+	if s == "" {
+		return nil
+	}
+	return Strings{s}
+}
+
+type Archtecture struct {
+	Url        Strings `json:"url"`
+	Hash       Strings `json:"hash,omitempty"`
+	ExtractDir Strings `json:"extract_dir,omitempty"`
+}
+
+// HashExtraction is "hash" of "autoupdate" which tells Scoop
+// where the hash of the new version is published.
+type HashExtraction struct {
+	Url      string `json:"url"`
+	Regex    string `json:"regex,omitempty"`
+	JsonPath string `json:"jsonpath,omitempty"`
+	// Mode is "extract", "json", "xpath", "rdf", "metalink", "fosshub",
+	// "sourceforge" or "download". Scoop guesses it when it is empty.
+	Mode string `json:"mode,omitempty"`
+}
+
+// HashExtractions is "hash" of "autoupdate": an object, or an array of
+// the objects for the files of "url" installed together.
+type HashExtractions []*HashExtraction
+
+func (h HashExtractions) MarshalJSON() ([]byte, error) {
+	if len(h) == 1 {
+		return ordered.Marshal(h[0])
+	}
+	return ordered.Marshal([]*HashExtraction(h))
+}
+
+func (h *HashExtractions) UnmarshalJSON(data []byte) error {
+	var one HashExtraction
+	if err := json.Unmarshal(data, &one); err == nil {
+		*h = HashExtractions{&one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]*HashExtraction)(h))
+}
+
+type AutoUpdateArchtecture struct {
+	Url        Strings         `json:"url"`
+	Hash       HashExtractions `json:"hash,omitempty"`
+	ExtractDir Strings         `json:"extract_dir,omitempty"`
+}
+
+type AutoUpdate struct {
+	Archtectures  map[string]*AutoUpdateArchtecture `json:"architecture,omitempty"`
+	UrlForAnyCPU  Strings                           `json:"url,omitempty"`
+	HashForAnyCPU HashExtractions                   `json:"hash,omitempty"`
+}
+
+// License is "license" of the manifest. It is written as the string of
+// Identifier, or as the object with the URL of the license file.
+type License struct {
+	// Identifier is an SPDX license identifier, or "Freeware", "Proprietary" and so on
+	Identifier string `json:"identifier"`
+	Url        string `json:"url,omitempty"`
+}
+
+// plainLicense is License without the methods for JSON
+type plainLicense License
+
+func (l License) MarshalJSON() ([]byte, error) {
+	if l.Url == "" {
+		return ordered.Marshal(l.Identifier)
+	}
+	return ordered.Marshal(plainLicense(l))
+}
+
+func (l *License) UnmarshalJSON(data []byte) error {
+	var identifier string
+	if err := json.Unmarshal(data, &identifier); err == nil {
+		*l = License{Identifier: identifier}
+		return nil
+	}
+	return json.Unmarshal(data, (*plainLicense)(l))
+}
+
+// Manifest is the manifest of Scoop. The fields it does not know are
+// kept when it is read with json.Unmarshal and written with json.Marshal.
+// "bin" is not written when Bin is nil, so that the manifests without it
+// (installed with "installer" and so on) do not get "bin": null.
+type Manifest struct {
+	Version       string                  `json:"version"`
+	Description   string                  `json:"description,omitempty"`
+	Homepage      string                  `json:"homepage,omitempty"`
+	License       *License                `json:"license,omitempty"`
+	Archtectures  map[string]*Archtecture `json:"architecture,omitempty"`
+	UrlForAnyCPU  Strings                 `json:"url,omitempty"`
+	HashForAnyCPU Strings                 `json:"hash,omitempty"`
+	Bin           any                     `json:"bin,omitempty"`
+	CheckVer      any                     `json:"checkver,omitempty"`
+	AutoUpdate    *AutoUpdate             `json:"autoupdate,omitempty"`
+
+	// source is the JSON read with UnmarshalJSON including the unknown fields
+	source []byte
+}
+
+// keyOrder is the order of the fields in the manifests of Scoop.
+// The generated fields which the template does not have are placed with it.
+var keyOrder = []string{
+	"##",
+	"32bit", "64bit", "arm64",
+	"version", "description", "homepage", "license", "notes",
+	"depends", "suggest", "architecture", "url", "hash",
+	"extract_dir", "extract_to", "pre_install", "installer",
+	"post_install", "uninstaller", "bin", "shortcuts", "persist",
+	"env_add_path", "env_set", "checkver", "autoupdate",
+}
+
+// plainManifest is Manifest without the methods for JSON
+type plainManifest Manifest
+
+func (m *Manifest) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*plainManifest)(m)); err != nil {
+		return err
+	}
+	m.source = bytes.Clone(data)
+	return nil
+}
+
+// MarshalJSON merges the fields of Manifest into the JSON read with
+// UnmarshalJSON, so that the unknown fields and the order of them are kept.
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	generated, err := ordered.Marshal((*plainManifest)(m))
+	if err != nil {
+		return nil, err
+	}
+	var doc, gen ordered.Object
+	if m.source != nil {
+		if err := json.Unmarshal(m.source, &doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(generated, &gen); err != nil {
+		return nil, err
+	}
+	if err := doc.Merge(&gen, keyOrder); err != nil {
+		return nil, err
+	}
+	return ordered.Marshal(&doc)
+}
+
+// Format returns the JSON indented with four spaces and ended with CRLF
+// as the manifests of Scoop are written.
+func (m *Manifest) Format() ([]byte, error) {
+	jsonBin, err := ordered.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return withCRLF(jsonBin)
+}
+
+func writeWithCRLF(source []byte, w io.Writer) error {
+	for {
+		before, after, found := bytes.Cut(source, []byte{'\n'})
+		_, err := w.Write(before)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte{'\r', '\n'})
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+		source = after
+	}
+}
+
+func withCRLF(source []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := writeWithCRLF(source, &buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// keysToSlice returns keys of map1 as the type []string or a string.
+// It returns nil for the empty map because "bin" must not be empty.
+func keysToSlice(map1 map[string]struct{}) any {
+	if len(map1) == 0 {
+		return nil
+	}
+	if len(map1) == 1 {
+		for binname := range map1 {
+			return binname
+		}
+	}
+	slice := make([]string, 0, len(map1))
+	for key := range map1 {
+		slice = append(slice, key)
+	}
+	sort.Strings(slice)
+	return slice
+}
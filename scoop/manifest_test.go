@@ -0,0 +1,100 @@
+package scoop
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMarshalKeepsTemplate(t *testing.T) {
+	template := `{"##":"comment","version":"1.0","notes":"hello","bin":"a.exe","persist":["data"]}`
+	var m Manifest
+	if err := json.Unmarshal([]byte(template), &m); err != nil {
+		t.Fatal(err.Error())
+	}
+	m.Version = "2.0"
+	m.Homepage = "https://example.com"
+	m.UrlForAnyCPU = Strings{"https://example.com/a-2.0.zip"}
+
+	result, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := `{"##":"comment","version":"2.0","homepage":"https://example.com","notes":"hello","url":"https://example.com/a-2.0.zip","bin":"a.exe","persist":["data"]}`
+	if string(result) != expect {
+		t.Fatalf("expect\n%s\nbut\n%s", expect, result)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	source := `{"version":"1.0","architecture":{"64bit":{"url":"old64","hash":"h64","bin":"x.exe"},"32bit":{"url":"old32","hash":"h32"}},"notes":"keep"}`
+	m := &Manifest{
+		Version: "2.0",
+		Archtectures: map[string]*Archtecture{
+			"64bit": {Url: Strings{"new64"}, Hash: Strings{"n64"}},
+			"arm64": {Url: Strings{"newarm"}, Hash: Strings{"narm"}},
+		},
+	}
+	result, err := Update([]byte(source), m, io.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var compact strings.Builder
+	for _, line := range strings.Split(string(result), "\r\n") {
+		compact.WriteString(strings.TrimSpace(line))
+	}
+	expect := `{"version": "2.0","architecture": {"64bit": {"url": "new64","hash": "n64","bin": "x.exe"},"arm64": {"url": "newarm","hash": "narm"}},"notes": "keep"}`
+	if compact.String() != expect {
+		t.Fatalf("expect\n%s\nbut\n%s", expect, compact.String())
+	}
+}
+
+func TestUpdateArray(t *testing.T) {
+	// The files installed together follow the file of the release in the arrays
+	source := `{"version":"1.0","url":["https://example.com/a-1.0.zip","https://example.com/a.ps1"],"hash":["h1","hps1"],"extract_dir":["a-1.0",""],"autoupdate":{"url":["https://example.com/a-$version.zip","https://example.com/a.ps1"],"hash":[{"url":"$url.sha256"},{"url":"$url.sha256"}]}}`
+	var current Manifest
+	if err := json.Unmarshal([]byte(source), &current); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(current.UrlForAnyCPU) != 2 || len(current.AutoUpdate.HashForAnyCPU) != 2 {
+		t.Fatalf("expect the arrays, but %#v", current)
+	}
+	m := &Manifest{
+		Version:       "2.0",
+		UrlForAnyCPU:  Strings{"https://example.com/a-2.0.zip"},
+		HashForAnyCPU: Strings{"h2"},
+	}
+	result, err := Update([]byte(source), m, io.Discard)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var compact strings.Builder
+	for _, line := range strings.Split(string(result), "\r\n") {
+		compact.WriteString(strings.TrimSpace(line))
+	}
+	expect := `{"version": "2.0","url": ["https://example.com/a-2.0.zip","https://example.com/a.ps1"],"hash": ["h2","hps1"],"extract_dir": ["a-1.0",""],"autoupdate": {"url": ["https://example.com/a-$version.zip","https://example.com/a.ps1"],"hash": [{"url": "$url.sha256"},{"url": "$url.sha256"}]}}`
+	if compact.String() != expect {
+		t.Fatalf("expect\n%s\nbut\n%s", expect, compact.String())
+	}
+}
+
+func TestMarshalWithoutBin(t *testing.T) {
+	// The apps installed with "installer" may have no "bin"
+	source := `{"version":"1.0","url":"https://example.com/a-1.0.exe","installer":{"file":"a-1.0.exe"}}`
+	var m Manifest
+	if err := json.Unmarshal([]byte(source), &m); err != nil {
+		t.Fatal(err.Error())
+	}
+	m.Version = "2.0"
+	m.UrlForAnyCPU = Strings{"https://example.com/a-2.0.exe"}
+
+	result, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := `{"version":"2.0","url":"https://example.com/a-2.0.exe","installer":{"file":"a-1.0.exe"}}`
+	if string(result) != expect {
+		t.Fatalf("expect\n%s\nbut\n%s", expect, result)
+	}
+}
@@ -0,0 +1,92 @@
+package scoop
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/hymkor/make-scoop-manifest/internal/cache"
+)
+
+// Options are the settings of Generator. Each field corresponds to
+// the option of the command line. Use NewOptions for the defaults.
+type Options struct {
+	// Template is the manifest JSON whose fields precede the generated ones (-inline, -stdin)
+	Template []byte
+	// AnyCPU does not use "architecture" of the manifest (-anycpu)
+	AnyCPU bool
+	// ExtractDir sets the parent directory of the executables into "extract_dir" (-p)
+	ExtractDir bool
+	// Keywords32 are the comma separated words in the names of the assets for 32bit (-32)
+	Keywords32 string
+	// Keywords64 are the comma separated words in the names of the assets for 64bit (-64)
+	Keywords64 string
+	// KeywordsArm64 are the comma separated words in the names of the assets for arm64 (-arm64)
+	KeywordsArm64 string
+	// PECheck reads the headers of the executables in the archives
+	// to confirm the architecture guessed from the name (-pecheck)
+	PECheck bool
+	// Strict makes the executables not for the architecture of the asset
+	// name an error instead of a warning (-strict)
+	Strict bool
+	// License is the value of "license" (-license)
+	License string
+	// Description is the value of "description" (-description)
+	Description string
+	// DownloadTo is the directory to save the downloaded assets (-downloadto)
+	DownloadTo string
+	// BinPattern are the comma separated patterns of the executables (-binpattern)
+	BinPattern string
+	// IgnoreWords are the comma separated words in the names of the assets to ignore (-ignore)
+	IgnoreWords string
+	// NoAutoUpdate disables "checkver" and "autoupdate" (-noautoupdate)
+	NoAutoUpdate bool
+	// Tag is the tag of the release to use instead of the newest one (-tag)
+	Tag string
+	// TagRegex is the regular expression with the named group "version"
+	// to extract the version from the tag, or a name of TagPresets.
+	// When it is empty, the prefix "v" of the tag is removed (-tagregex)
+	TagRegex string
+	// Latest uses the release the forge marks as the latest (-latest)
+	Latest bool
+	// Prerelease uses the newest release including pre-releases (-prerelease)
+	Prerelease bool
+	// Token is the token for the API. When it is empty, the environment
+	// variable for the forge ($GITHUB_TOKEN, $GITLAB_TOKEN ...) is used (-token)
+	Token string
+	// Forge is "github", "gitlab" or "gitea". When it is empty, it is guessed
+	// from the hostname of the repository (-forge)
+	Forge string
+	// GithubServer is the URL of GitHub Enterprise Server.
+	// When it is empty, $GITHUB_SERVER_URL or https://github.com is used (-githubserver)
+	GithubServer string
+	// GithubApi is the URL of REST API of GitHub Enterprise Server.
+	// When it is empty, $GITHUB_API_URL or SERVER/api/v3 is used (-githubapi)
+	GithubApi string
+	// Jobs is the number of the assets downloaded at the same time (-jobs)
+	Jobs int
+	// CacheDir is the directory to record the hashes and the files of
+	// the downloaded assets. The assets not modified since then are not
+	// downloaded again. Empty disables the cache (-nocache)
+	CacheDir string
+	// LocalFiles are the paths of the local files used instead of
+	// downloading the assets of the same names. The keys are the names.
+	LocalFiles map[string]string
+	// Log receives the progress messages (default: discarded)
+	Log io.Writer
+	// Client sends all the requests (default: http.DefaultClient)
+	Client *http.Client
+}
+
+// NewOptions returns Options with the defaults of the command line.
+func NewOptions() *Options {
+	cacheDir, _ := cache.DefaultDir()
+	return &Options{
+		Keywords32:    "386,486,586,686,32bit,win32,ia32,x86",
+		Keywords64:    "amd64,64bit,win64,x86_64,x64",
+		KeywordsArm64: "arm64,aarch64",
+		BinPattern:    "*.exe",
+		IgnoreWords:   "linux,macos,freebsd,netbsd,darwin,plan9",
+		Jobs:          4,
+		CacheDir:      cacheDir,
+	}
+}
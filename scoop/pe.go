@@ -0,0 +1,103 @@
+package scoop
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/hymkor/make-scoop-manifest/internal/archive"
+)
+
+// machineArch are the architectures for the machine types of PE
+var machineArch = map[uint16]string{
+	pe.IMAGE_FILE_MACHINE_I386:  "32bit",
+	pe.IMAGE_FILE_MACHINE_AMD64: "64bit",
+	pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+	pe.IMAGE_FILE_MACHINE_ARMNT: arm32,
+	pe.IMAGE_FILE_MACHINE_ARM:   arm32,
+}
+
+// peHeadSize is the size of the head of an executable read to find
+// its machine type. The PE header follows the DOS stub in it.
+const peHeadSize = 4096
+
+// parseMachine returns the architecture in the PE header in head,
+// or "" when head is not the head of PE like scripts.
+func parseMachine(head []byte) string {
+	if len(head) < 0x40 || head[0] != 'M' || head[1] != 'Z' {
+		return ""
+	}
+	// e_lfanew is the offset of the PE header
+	offset := int64(binary.LittleEndian.Uint32(head[0x3C:]))
+	if offset+4+int64(binary.Size(pe.FileHeader{})) > int64(len(head)) {
+		return ""
+	}
+	if !bytes.Equal(head[offset:offset+4], []byte("PE\x00\x00")) {
+		return ""
+	}
+	var fh pe.FileHeader
+	if err := binary.Read(bytes.NewReader(head[offset+4:]), binary.LittleEndian, &fh); err != nil {
+		return ""
+	}
+	if arch, ok := machineArch[fh.Machine]; ok {
+		return arch
+	}
+	return fmt.Sprintf("machine type 0x%04X", fh.Machine)
+}
+
+// readHead reads the head of r for parseMachine
+func readHead(r io.Reader) ([]byte, error) {
+	head := make([]byte, peHeadSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// readMachine returns the architecture of the executable in the archive
+// reading only its head. It returns "" for the files which are not PE.
+func readMachine(open archive.Opener) (string, error) {
+	r, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	head, err := readHead(r)
+	if err != nil {
+		return "", err
+	}
+	return parseMachine(head), nil
+}
+
+// machineOf returns the architecture of all the executables in the asset.
+// It returns "" when there are no executables or they are not the same.
+func machineOf(machines map[string]string) string {
+	var result string
+	for _, machine := range machines {
+		if result != "" && result != machine {
+			return ""
+		}
+		result = machine
+	}
+	return result
+}
+
+// checkMachines reports the executables in the asset which are not for
+// the architecture guessed from its name: as a warning, or as an error
+// with Options.Strict.
+func (g *Generator) checkMachines(name, bits string, machines map[string]string) error {
+	for _, exe := range slices.Sorted(maps.Keys(machines)) {
+		if machine := machines[exe]; machine != bits {
+			if g.options.Strict {
+				return fmt.Errorf("%s: %s is for %s, not %s", name, exe, machine, bits)
+			}
+			fmt.Fprintf(g.log, "Warning: %s: %s is for %s, not %s\n", name, exe, machine, bits)
+		}
+	}
+	return nil
+}
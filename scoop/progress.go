@@ -0,0 +1,115 @@
+package scoop
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// progressInterval is the interval of the progress lines of a download
+const progressInterval = time.Second
+
+// progressReader reports the bytes read from r, the rate and ETA to log
+// at most once every progressInterval and at the end.
+type progressReader struct {
+	r     io.Reader
+	name  string
+	total int64
+	log   io.Writer
+	n     int64
+	start time.Time
+	last  time.Time
+}
+
+func newProgressReader(r io.Reader, name string, total int64, log io.Writer) *progressReader {
+	now := time.Now()
+	return &progressReader{
+		r:     r,
+		name:  name,
+		total: total,
+		log:   log,
+		start: now,
+		last:  now,
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	now := time.Now()
+	if err == io.EOF {
+		elapsed := now.Sub(p.start)
+		fmt.Fprintf(p.log, "%s: %s in %s (%s/s)\n",
+			p.name, formatBytes(p.n), elapsed.Round(time.Millisecond), formatBytes(rate(p.n, elapsed)))
+	} else if now.Sub(p.last) >= progressInterval {
+		p.last = now
+		p.report(now)
+	}
+	return n, err
+}
+
+func (p *progressReader) report(now time.Time) {
+	elapsed := now.Sub(p.start)
+	bps := rate(p.n, elapsed)
+	if p.total <= 0 {
+		fmt.Fprintf(p.log, "%s: %s (%s/s)\n", p.name, formatBytes(p.n), formatBytes(bps))
+		return
+	}
+	fmt.Fprintf(p.log, "%s: %s / %s (%s/s, ETA %s)\n",
+		p.name, formatBytes(p.n), formatBytes(p.total), formatBytes(bps), eta(p.total-p.n, bps))
+}
+
+// etaLimit is the longest ETA shown. The longer one is shown as "--"
+// as well as the one when nothing is received yet.
+const etaLimit = 100 * time.Hour
+
+// eta returns the time to receive the remaining bytes at bps bytes per second
+func eta(remaining, bps int64) string {
+	if bps <= 0 {
+		return "--"
+	}
+	// The seconds are computed first because the nanoseconds of
+	// remaining bytes can overflow time.Duration at a low rate.
+	seconds := (remaining + bps - 1) / bps
+	if seconds > int64(etaLimit/time.Second) {
+		return "--"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// rate returns the bytes per second
+func rate(n int64, elapsed time.Duration) int64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(n) / elapsed.Seconds())
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		value /= unit
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%.1f TiB", value/unit)
+}
+
+// syncWriter serializes the writes of the workers into w,
+// so that the lines of them are not mixed.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(b)
+}
@@ -0,0 +1,24 @@
+package scoop
+
+import (
+	"testing"
+)
+
+func TestEta(t *testing.T) {
+	for _, tc := range []struct {
+		remaining int64
+		bps       int64
+		expect    string
+	}{
+		{1024, 0, "--"},
+		{1024, 1024, "1s"},
+		{1025, 1024, "2s"},
+		{90 * 1024 * 1024, 1024 * 1024, "1m30s"},
+		{10 << 30, 1, "--"},
+		{1 << 62, 1, "--"},
+	} {
+		if result := eta(tc.remaining, tc.bps); result != tc.expect {
+			t.Errorf("eta(%d, %d): expect %q, but %q", tc.remaining, tc.bps, tc.expect, result)
+		}
+	}
+}
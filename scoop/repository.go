@@ -0,0 +1,184 @@
+package scoop
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/internal/forge"
+	"github.com/hymkor/make-scoop-manifest/internal/gitea"
+	"github.com/hymkor/make-scoop-manifest/internal/github"
+	"github.com/hymkor/make-scoop-manifest/internal/gitlab"
+)
+
+// repository is the repository on the forge which releases the application.
+type repository struct {
+	provider forge.Provider
+	host     string
+	owner    string
+	repos    string
+}
+
+// pathPrefix returns the path of the repository in the URLs of the assets,
+// where the version is never found.
+func (r *repository) pathPrefix() string {
+	return "/" + r.owner + "/" + r.repos + "/"
+}
+
+// githubServer returns the URL of GitHub given with Options.GithubServer or
+// $GITHUB_SERVER_URL. It is https://github.com unless GitHub Enterprise
+// Server is used.
+func (g *Generator) githubServer() string {
+	return strings.TrimSuffix(cmp.Or(g.options.GithubServer, os.Getenv("GITHUB_SERVER_URL"), github.DefaultServerUrl), "/")
+}
+
+// githubHost returns the hostname of githubServer()
+func (g *Generator) githubHost() string {
+	if u, err := url.Parse(g.githubServer()); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "github.com"
+}
+
+// forgeKind returns "github", "gitlab" or "gitea" for the host.
+// Options.Forge precedes the guess from the hostname.
+func (g *Generator) forgeKind(host string) (string, error) {
+	if g.options.Forge != "" {
+		return strings.ToLower(g.options.Forge), nil
+	}
+	lowerHost := strings.ToLower(host)
+	switch {
+	case lowerHost == "github.com", strings.EqualFold(host, g.githubHost()):
+		return "github", nil
+	case strings.Contains(lowerHost, "gitlab"):
+		return "gitlab", nil
+	case lowerHost == "codeberg.org",
+		strings.Contains(lowerHost, "gitea"),
+		strings.Contains(lowerHost, "forgejo"):
+		return "gitea", nil
+	}
+	return "", fmt.Errorf("%s: unknown forge. Specify -forge github, gitlab or gitea", host)
+}
+
+// newProvider returns forge.Provider for the host with the token
+// given with Options.Token or the environment variable for the forge.
+func (g *Generator) newProvider(host string) (forge.Provider, error) {
+	kind, err := g.forgeKind(host)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "github":
+		token := cmp.Or(g.options.Token, github.TokenFromEnv())
+		if !strings.EqualFold(host, g.githubHost()) {
+			return github.Provider{
+				ServerUrl: "https://" + host,
+				Token:     token,
+				Client:    g.options.Client,
+			}, nil
+		}
+		return github.Provider{
+			ServerUrl: g.githubServer(),
+			ApiUrl:    cmp.Or(g.options.GithubApi, os.Getenv("GITHUB_API_URL")),
+			Token:     token,
+			Client:    g.options.Client,
+		}, nil
+	case "gitlab":
+		return &gitlab.Provider{
+			Host:   host,
+			Token:  cmp.Or(g.options.Token, gitlab.TokenFromEnv()),
+			Client: g.options.Client,
+		}, nil
+	case "gitea", "forgejo", "codeberg":
+		return &gitea.Provider{
+			Host:   host,
+			Token:  cmp.Or(g.options.Token, gitea.TokenFromEnv()),
+			Client: g.options.Client,
+		}, nil
+	}
+	return nil, fmt.Errorf("%s: unknown forge. Specify -forge github, gitlab or gitea", kind)
+}
+
+var (
+	rxRepositoryG = regexp.MustCompile(`^git@([^:]+):(.+)$`)
+	rxRepositoryU = regexp.MustCompile(`^https?://([^/]+)/(.+)$`)
+	rxRepositoryH = regexp.MustCompile(`^([^/]+)/([^/]+)`)
+)
+
+// IsRepository reports whether arg is written as OWNER/REPOS,
+// a URL or git@HOST:OWNER/REPOS.git
+func IsRepository(arg string) bool {
+	return rxRepositoryG.MatchString(arg) ||
+		rxRepositoryU.MatchString(arg) ||
+		rxRepositoryH.MatchString(arg)
+}
+
+// parseRepository returns the host, the owner and the name of
+// the repository written as OWNER/REPOS, a URL or git@HOST:OWNER/REPOS.git
+func (g *Generator) parseRepository(arg string) (host, owner, repos string, ok bool) {
+	var path string
+	if m := rxRepositoryG.FindStringSubmatch(arg); m != nil {
+		host, path = m[1], m[2]
+	} else if m := rxRepositoryU.FindStringSubmatch(arg); m != nil {
+		host, path = m[1], m[2]
+	} else if m := rxRepositoryH.FindStringSubmatch(arg); m != nil {
+		return g.githubHost(), m[1], m[2], true
+	} else {
+		return "", "", "", false
+	}
+	kind, _ := g.forgeKind(host)
+	if kind == "gitlab" {
+		// GitLab allows nested groups and puts the sub pages after "/-/"
+		path, _, _ = strings.Cut(path, "/-/")
+	}
+	path = strings.TrimSuffix(path, "/")
+	if strings.EqualFold(filepath.Ext(path), ".git") {
+		path = path[:len(path)-4]
+	}
+	if kind == "gitlab" {
+		i := strings.LastIndex(path, "/")
+		if i < 0 {
+			return "", "", "", false
+		}
+		return host, path[:i], path[i+1:], true
+	}
+	m := rxRepositoryH.FindStringSubmatch(path)
+	if m == nil {
+		return "", "", "", false
+	}
+	return host, m[1], m[2], true
+}
+
+// openRepository returns the repository written as arg.
+// The same repository is returned for the same arg.
+func (g *Generator) openRepository(arg string) (*repository, error) {
+	if repo, ok := g.repositories[arg]; ok {
+		return repo, nil
+	}
+	host, owner, repos, ok := g.parseRepository(arg)
+	if !ok {
+		return nil, fmt.Errorf("%s: not a repository. Write OWNER/REPOS or the URL", arg)
+	}
+	provider, err := g.newProvider(host)
+	if err != nil {
+		return nil, err
+	}
+	if host != "github.com" {
+		fmt.Fprintln(g.log, "Host:", host)
+	}
+	fmt.Fprintln(g.log, "Owner:", owner)
+	fmt.Fprintln(g.log, "Repos:", repos)
+
+	repo := &repository{
+		provider: provider,
+		host:     host,
+		owner:    owner,
+		repos:    repos,
+	}
+	g.repositories[arg] = repo
+	return repo, nil
+}
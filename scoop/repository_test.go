@@ -0,0 +1,127 @@
+package scoop
+
+import (
+	"testing"
+)
+
+func TestParseRepository(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "")
+	tests := []struct {
+		arg          string
+		forge        string
+		githubServer string
+		host         string
+		owner        string
+		repos        string
+		ok           bool
+	}{
+		{arg: "hymkor/csvi", host: "github.com", owner: "hymkor", repos: "csvi", ok: true},
+		{arg: "https://github.com/hymkor/csvi.git", host: "github.com", owner: "hymkor", repos: "csvi", ok: true},
+		{arg: "git@github.com:hymkor/csvi.git", host: "github.com", owner: "hymkor", repos: "csvi", ok: true},
+		{arg: "https://github.com/hymkor/csvi/releases/tag/v1.0.0", host: "github.com", owner: "hymkor", repos: "csvi", ok: true},
+		{arg: "https://gitlab.com/group/sub/app/-/releases", host: "gitlab.com", owner: "group/sub", repos: "app", ok: true},
+		{arg: "git@gitlab.com:group/app.git", host: "gitlab.com", owner: "group", repos: "app", ok: true},
+		{arg: "https://gitlab.com/app", ok: false},
+		{arg: "https://codeberg.org/o/r/", host: "codeberg.org", owner: "o", repos: "r", ok: true},
+		{arg: "https://git.example.com/group/sub/app", forge: "gitlab", host: "git.example.com", owner: "group/sub", repos: "app", ok: true},
+		{arg: "https://git.example.com/o/r/releases", forge: "gitea", host: "git.example.com", owner: "o", repos: "r", ok: true},
+		{arg: "o/r", githubServer: "https://ghe.example.com/", host: "ghe.example.com", owner: "o", repos: "r", ok: true},
+		{arg: "csvi", ok: false},
+	}
+	for _, tt := range tests {
+		options := NewOptions()
+		options.Forge = tt.forge
+		options.GithubServer = tt.githubServer
+		g := New(options)
+		host, owner, repos, ok := g.parseRepository(tt.arg)
+		if ok != tt.ok || host != tt.host || owner != tt.owner || repos != tt.repos {
+			t.Errorf("%s: expect %q %q %q %v, but %q %q %q %v",
+				tt.arg, tt.host, tt.owner, tt.repos, tt.ok, host, owner, repos, ok)
+		}
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		host         string
+		forge        string
+		githubServer string
+		githubApi    string
+		serverEnv    string
+		name         string
+		homepage     string
+		releasesApi  string
+	}{
+		{
+			host:        "github.com",
+			name:        "GitHub",
+			homepage:    "https://github.com/o/r",
+			releasesApi: "https://api.github.com/repos/o/r/releases",
+		},
+		{
+			host:         "ghe.example.com",
+			githubServer: "https://ghe.example.com/",
+			name:         "GitHub",
+			homepage:     "https://ghe.example.com/o/r",
+			releasesApi:  "https://ghe.example.com/api/v3/repos/o/r/releases",
+		},
+		{
+			host:         "ghe.example.com",
+			githubServer: "https://ghe.example.com",
+			githubApi:    "https://api.ghe.example.com/",
+			name:         "GitHub",
+			homepage:     "https://ghe.example.com/o/r",
+			releasesApi:  "https://api.ghe.example.com/repos/o/r/releases",
+		},
+		{
+			host:        "ghe.example.com",
+			serverEnv:   "https://ghe.example.com",
+			name:        "GitHub",
+			homepage:    "https://ghe.example.com/o/r",
+			releasesApi: "https://ghe.example.com/api/v3/repos/o/r/releases",
+		},
+		{
+			// Another server than GITHUB_SERVER_URL is given with -forge
+			host:        "ghe2.example.com",
+			forge:       "GitHub",
+			name:        "GitHub",
+			homepage:    "https://ghe2.example.com/o/r",
+			releasesApi: "https://ghe2.example.com/api/v3/repos/o/r/releases",
+		},
+		{
+			host:        "gitlab.example.com",
+			name:        "GitLab",
+			homepage:    "https://gitlab.example.com/o/r",
+			releasesApi: "https://gitlab.example.com/api/v4/projects/o%2Fr/releases",
+		},
+		{
+			host:        "git.example.com",
+			forge:       "forgejo",
+			name:        "Gitea",
+			homepage:    "https://git.example.com/o/r",
+			releasesApi: "https://git.example.com/api/v1/repos/o/r/releases",
+		},
+	}
+	for _, tt := range tests {
+		t.Setenv("GITHUB_SERVER_URL", tt.serverEnv)
+		t.Setenv("GITHUB_API_URL", "")
+		options := NewOptions()
+		options.Forge = tt.forge
+		options.GithubServer = tt.githubServer
+		options.GithubApi = tt.githubApi
+		p, err := New(options).newProvider(tt.host)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.host, err.Error())
+		}
+		if p.Name() != tt.name || p.Homepage("o", "r") != tt.homepage || p.ReleasesApi("o", "r") != tt.releasesApi {
+			t.Errorf("%s: expect %s %s %s, but %s %s %s", tt.host,
+				tt.name, tt.homepage, tt.releasesApi,
+				p.Name(), p.Homepage("o", "r"), p.ReleasesApi("o", "r"))
+		}
+	}
+
+	t.Setenv("GITHUB_SERVER_URL", "")
+	if _, err := New(NewOptions()).newProvider("git.example.com"); err == nil {
+		t.Fatal("expect the error for the unknown forge")
+	}
+}
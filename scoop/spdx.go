@@ -0,0 +1,158 @@
+package scoop
+
+import (
+	"regexp"
+	"strings"
+)
+
+// spdxIds are the SPDX license identifiers used by the applications
+// in the buckets of Scoop. The keys are in lower case to find the
+// identifiers written in the wrong case like "mit".
+var spdxIds = map[string]string{}
+
+func init() {
+	for _, id := range []string{
+		"0BSD", "AFL-3.0", "AGPL-1.0", "AGPL-3.0", "AGPL-3.0-only", "AGPL-3.0-or-later",
+		"Apache-1.1", "Apache-2.0", "APSL-2.0", "Artistic-1.0", "Artistic-2.0",
+		"BlueOak-1.0.0", "BSD-1-Clause", "BSD-2-Clause", "BSD-2-Clause-Patent",
+		"BSD-3-Clause", "BSD-3-Clause-Clear", "BSD-4-Clause", "BSL-1.0",
+		"CC-BY-3.0", "CC-BY-4.0", "CC-BY-NC-4.0", "CC-BY-NC-SA-4.0", "CC-BY-ND-4.0",
+		"CC-BY-SA-3.0", "CC-BY-SA-4.0", "CC0-1.0", "CDDL-1.0", "CDDL-1.1",
+		"CECILL-2.1", "CPL-1.0", "ECL-2.0", "EPL-1.0", "EPL-2.0", "EUPL-1.1", "EUPL-1.2",
+		"FTL", "GFDL-1.3", "GFDL-1.3-only", "GFDL-1.3-or-later",
+		"GPL-1.0", "GPL-1.0-only", "GPL-1.0-or-later",
+		"GPL-2.0", "GPL-2.0-only", "GPL-2.0-or-later",
+		"GPL-3.0", "GPL-3.0-only", "GPL-3.0-or-later",
+		"HPND", "ICU", "IJG", "Info-ZIP", "IPL-1.0", "ISC",
+		"LGPL-2.0", "LGPL-2.0-only", "LGPL-2.0-or-later",
+		"LGPL-2.1", "LGPL-2.1-only", "LGPL-2.1-or-later",
+		"LGPL-3.0", "LGPL-3.0-only", "LGPL-3.0-or-later",
+		"Libpng", "LPPL-1.3c", "MIT", "MIT-0", "MPL-1.1", "MPL-2.0",
+		"MS-PL", "MS-RL", "MulanPSL-2.0", "NCSA", "ODbL-1.0", "OFL-1.1",
+		"OpenSSL", "OSL-3.0", "PHP-3.01", "PostgreSQL", "Python-2.0", "Ruby",
+		"Sleepycat", "Unicode-DFS-2016", "Unlicense", "UPL-1.0", "Vim",
+		"W3C", "WTFPL", "X11", "Zlib", "ZPL-2.1",
+	} {
+		spdxIds[strings.ToLower(id)] = id
+	}
+}
+
+// scoopLicenses are the values of "license" Scoop defines besides SPDX
+var scoopLicenses = []string{"Freeware", "Proprietary", "Public Domain", "Shareware", "Unknown"}
+
+// licenseNames are the names of the licenses shown by GitHub and so on
+// for their SPDX identifiers. The GNU licenses have the identifiers
+// without "-only" as GitHub gives, because the names do not tell it.
+var licenseNames = map[string]string{
+	"academic free license v3.0":                                 "AFL-3.0",
+	"apache license 2.0":                                         "Apache-2.0",
+	"artistic license 2.0":                                       "Artistic-2.0",
+	"blue oak model license 1.0.0":                               "BlueOak-1.0.0",
+	"boost software license 1.0":                                 "BSL-1.0",
+	`bsd 2-clause "simplified" license`:                          "BSD-2-Clause",
+	`bsd 3-clause "new" or "revised" license`:                    "BSD-3-Clause",
+	"bsd 3-clause clear license":                                 "BSD-3-Clause-Clear",
+	"bsd 4-clause \"original\" or \"old\" license":               "BSD-4-Clause",
+	"bsd zero clause license":                                    "0BSD",
+	"creative commons attribution 4.0 international":             "CC-BY-4.0",
+	"creative commons attribution share alike 4.0 international": "CC-BY-SA-4.0",
+	"creative commons zero v1.0 universal":                       "CC0-1.0",
+	"do what the f*ck you want to public license":                "WTFPL",
+	"eclipse public license 1.0":                                 "EPL-1.0",
+	"eclipse public license 2.0":                                 "EPL-2.0",
+	"educational community license v2.0":                         "ECL-2.0",
+	"european union public license 1.1":                          "EUPL-1.1",
+	"european union public license 1.2":                          "EUPL-1.2",
+	"gnu affero general public license v3.0":                     "AGPL-3.0",
+	"gnu general public license v2.0":                            "GPL-2.0",
+	"gnu general public license v3.0":                            "GPL-3.0",
+	"gnu lesser general public license v2.1":                     "LGPL-2.1",
+	"gnu lesser general public license v3.0":                     "LGPL-3.0",
+	"isc license":                                                "ISC",
+	"latex project public license v1.3c":                         "LPPL-1.3c",
+	"microsoft public license":                                   "MS-PL",
+	"microsoft reciprocal license":                               "MS-RL",
+	"mit license":                                                "MIT",
+	"mit no attribution":                                         "MIT-0",
+	"mozilla public license 2.0":                                 "MPL-2.0",
+	"mulan permissive software license, version 2":               "MulanPSL-2.0",
+	"odc open database license v1.0":                             "ODbL-1.0",
+	"open software license 3.0":                                  "OSL-3.0",
+	"postgresql license":                                         "PostgreSQL",
+	"sil open font license 1.1":                                  "OFL-1.1",
+	"the unlicense":                                              "Unlicense",
+	"universal permissive license v1.0":                          "UPL-1.0",
+	"university of illinois/ncsa open source license":            "NCSA",
+	"vim license":                                                "Vim",
+	"zlib license":                                               "Zlib",
+}
+
+// isSpdxExpression reports whether s is an SPDX license expression like
+// "MIT", "GPL-2.0-or-later WITH Classpath-exception-2.0" or "(MIT OR Apache-2.0)".
+// "LicenseRef-..." is accepted as the identifier of a custom license.
+// The identifiers must be written in their canonical case.
+func isSpdxExpression(s string) bool {
+	s = strings.NewReplacer("(", " ", ")", " ").Replace(s)
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return false
+	}
+	afterWith := false
+	for i, f := range fields {
+		if i%2 == 1 {
+			if f != "AND" && f != "OR" && f != "WITH" {
+				return false
+			}
+			afterWith = f == "WITH"
+			continue
+		}
+		if afterWith || strings.HasPrefix(f, "LicenseRef-") {
+			continue
+		}
+		id := strings.TrimSuffix(f, "+")
+		if spdxIds[strings.ToLower(id)] != id {
+			return false
+		}
+	}
+	return len(fields)%2 == 1
+}
+
+// rxSpdxWord matches the identifiers and the operators in SPDX license expressions
+var rxSpdxWord = regexp.MustCompile(`[^\s()]+`)
+
+// canonicalSpdx returns the SPDX license expression s with the identifiers
+// and the operators in their canonical case like "MIT OR Apache-2.0" for
+// "mit or apache-2.0", and whether it is an SPDX license expression.
+func canonicalSpdx(s string) (string, bool) {
+	afterWith := false
+	result := rxSpdxWord.ReplaceAllStringFunc(s, func(word string) string {
+		switch upper := strings.ToUpper(word); upper {
+		case "AND", "OR", "WITH":
+			afterWith = upper == "WITH"
+			return upper
+		}
+		if afterWith || strings.HasPrefix(word, "LicenseRef-") {
+			return word
+		}
+		id, plus := strings.CutSuffix(word, "+")
+		if canonical, ok := spdxIds[strings.ToLower(id)]; ok {
+			id = canonical
+		}
+		if plus {
+			id += "+"
+		}
+		return id
+	})
+	return result, isSpdxExpression(result)
+}
+
+// spdxOf returns the SPDX identifier for the license name like "MIT License",
+// or the identifier written in the wrong case like "mit".
+func spdxOf(name string) (string, bool) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if id, ok := licenseNames[lower]; ok {
+		return id, true
+	}
+	id, ok := spdxIds[lower]
+	return id, ok
+}
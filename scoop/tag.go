@@ -0,0 +1,126 @@
+package scoop
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TagPresets are the names usable as Options.TagRegex instead of
+// the regular expressions for the common styles of the tags.
+var TagPresets = map[string]string{
+	// v: v1.2.3 or 1.2.3 (the default)
+	"v": `^v?(?<version>.+)$`,
+	// number: the first dotted number in the tag like release-1.4.2,
+	// v1.2.3-windows or 20240301
+	"number": `(?<version>\d+(?:\.\d+)*)`,
+	// slash: the tags of the monorepos like app/v2.0.0 or pkg/app/1.0
+	"slash": `^(?:[^/]+/)+v?(?<version>\d[^/]*)$`,
+	// at: the tags of the monorepos like app@1.2.3 or @scope/app@1.2.3
+	"at": `^@?[^@]+@v?(?<version>\d.*)$`,
+}
+
+// tagRule extracts the version from the tag with the regular expression
+// which has the named group "version".
+type tagRule struct {
+	rx *regexp.Regexp
+}
+
+// compileTagRule compiles Options.TagRegex. The empty string means the
+// default rule which removes the prefix "v" from any tag.
+func compileTagRule(s string) (*tagRule, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if preset, ok := TagPresets[s]; ok {
+		s = preset
+	}
+	rx, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("tagregex: %w", err)
+	}
+	if rx.SubexpIndex("version") < 0 {
+		return nil, fmt.Errorf("tagregex: %q has no named group \"version\"", s)
+	}
+	return &tagRule{rx: rx}, nil
+}
+
+// split returns the version in the tag with the texts before and after it,
+// and whether the tag follows the rule.
+func (t *tagRule) split(tag string) (prefix, version, suffix string, ok bool) {
+	if t == nil {
+		version = strings.TrimPrefix(tag, "v")
+		return tag[:len(tag)-len(version)], version, "", true
+	}
+	i := t.rx.SubexpIndex("version")
+	m := t.rx.FindStringSubmatchIndex(tag)
+	if m == nil || m[2*i] >= m[2*i+1] {
+		return "", "", "", false
+	}
+	return tag[:m[2*i]], tag[m[2*i]:m[2*i+1]], tag[m[2*i+1]:], true
+}
+
+// tagGroup is a named group of the rule other than "version" and
+// its position in the tag.
+type tagGroup struct {
+	name       string
+	start, end int
+}
+
+// groups returns the named groups other than "version" matched in the tag
+// in the order of their positions. The groups which overlap the version
+// or the previous ones are not returned because "checkver" can not
+// capture them as the literal texts.
+func (t *tagRule) groups(tag string) []tagGroup {
+	if t == nil {
+		return nil
+	}
+	m := t.rx.FindStringSubmatchIndex(tag)
+	if m == nil {
+		return nil
+	}
+	v := t.rx.SubexpIndex("version")
+	var found []tagGroup
+	for i, name := range t.rx.SubexpNames() {
+		start, end := m[2*i], m[2*i+1]
+		if name == "" || name == "version" || start < 0 || start >= end {
+			continue
+		}
+		if start < m[2*v+1] && m[2*v] < end {
+			continue
+		}
+		found = append(found, tagGroup{name: name, start: start, end: end})
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].start < found[j].start })
+	var result []tagGroup
+	for _, g := range found {
+		if len(result) > 0 && g.start < result[len(result)-1].end {
+			continue
+		}
+		result = append(result, g)
+	}
+	return result
+}
+
+// matches returns the texts of the named groups in the tag by their names,
+// which Scoop gives to "autoupdate" as $matchTag and so on when the regex
+// of "checkver" has the same groups.
+func (t *tagRule) matches(tag string) map[string]string {
+	groups := t.groups(tag)
+	if len(groups) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(groups))
+	for _, g := range groups {
+		result[g.name] = tag[g.start:g.end]
+	}
+	return result
+}
+
+// version returns the version in the tag and whether the tag follows
+// the rule.
+func (t *tagRule) version(tag string) (string, bool) {
+	_, version, _, ok := t.split(tag)
+	return version, ok
+}
@@ -0,0 +1,78 @@
+package scoop
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestTagRule(t *testing.T) {
+	for _, tc := range []struct {
+		regex  string
+		tag    string
+		expect string
+		ok     bool
+	}{
+		{regex: "", tag: "v1.2.3", expect: "1.2.3", ok: true},
+		{regex: "v", tag: "1.2.3", expect: "1.2.3", ok: true},
+		{regex: "number", tag: "release-1.4.2", expect: "1.4.2", ok: true},
+		{regex: "number", tag: "v1.2.3-windows", expect: "1.2.3", ok: true},
+		{regex: "number", tag: "20240301", expect: "20240301", ok: true},
+		{regex: "slash", tag: "app/v2.0.0", expect: "2.0.0", ok: true},
+		{regex: "slash", tag: "v2.0.0", ok: false},
+		{regex: "at", tag: "@scope/app@1.2.3", expect: "1.2.3", ok: true},
+		{regex: `^app-b/v(?P<version>.+)$`, tag: "app-a/v1.0.0", ok: false},
+		{regex: `^app-b/v(?<version>.+)$`, tag: "app-b/v1.0.0", expect: "1.0.0", ok: true},
+	} {
+		rule, err := compileTagRule(tc.regex)
+		if err != nil {
+			t.Fatalf("%q: %s", tc.regex, err.Error())
+		}
+		version, ok := rule.version(tc.tag)
+		if version != tc.expect || ok != tc.ok {
+			t.Errorf("%q %q: expect %q,%v but %q,%v", tc.regex, tc.tag, tc.expect, tc.ok, version, ok)
+		}
+	}
+	if _, err := compileTagRule(`^v(.+)$`); err == nil {
+		t.Error("the regex without the group \"version\" must be an error")
+	}
+}
+
+func TestTagGroups(t *testing.T) {
+	for _, tc := range []struct {
+		regex   string
+		tag     string
+		pattern string
+		matches map[string]string
+	}{
+		{regex: "", tag: "v1.2.3", pattern: `v([\d.]+)`},
+		{regex: "slash", tag: "app/v2.0.0", pattern: `app/v([\d.]+)`},
+		{
+			regex:   `^(?<tag>[a-z]+)/v(?<version>[\d.]+)$`,
+			tag:     "cli/v0.5.0",
+			pattern: `(?<tag>cli)/v(?<version>[\d.]+)`,
+			matches: map[string]string{"tag": "cli"},
+		},
+		{
+			regex:   `^v(?<version>[\d.]+)-(?<os>[a-z]+)$`,
+			tag:     "v1.0-windows",
+			pattern: `v(?<version>[\d.]+)-(?<os>windows)`,
+			matches: map[string]string{"os": "windows"},
+		},
+		// The group which has the version can not be captured literally
+		{regex: `^(?<tag>v(?<version>[\d.]+))$`, tag: "v1.0", pattern: `v([\d.]+)`},
+	} {
+		rule, err := compileTagRule(tc.regex)
+		if err != nil {
+			t.Fatalf("%q: %s", tc.regex, err.Error())
+		}
+		if matches := rule.matches(tc.tag); !maps.Equal(matches, tc.matches) {
+			t.Errorf("%q %q: expect %v, but %v", tc.regex, tc.tag, tc.matches, matches)
+		}
+		g := &Generator{tags: rule}
+		release := &Release{Tag: tc.tag}
+		release.version, _ = rule.version(tc.tag)
+		if pattern := g.tagPattern(release); pattern != tc.pattern {
+			t.Errorf("%q %q: expect %s, but %s", tc.regex, tc.tag, tc.pattern, pattern)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package scoop
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// versionVariable is a variable of Scoop for "autoupdate" and its value
+type versionVariable struct {
+	name  string
+	value string
+}
+
+var (
+	rxVersionSeparator = regexp.MustCompile(`[._-]`)
+	rxMatchHead        = regexp.MustCompile(`^(\d+\.\d+(?:\.\d+)?)(.*)$`)
+)
+
+// versionVariables returns the variables which Scoop defines for the version
+// in the order of preference. matches are the named groups of the regex of
+// "checkver" like {"tag": "v1.0"} for $matchTag.
+func versionVariables(version string, matches map[string]string) []versionVariable {
+	firstPart, _, _ := strings.Cut(version, "-")
+	parts := strings.Split(firstPart, ".")
+	part := func(i int) string {
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+	vars := []versionVariable{
+		{"$version", version},
+		{"$dotVersion", rxVersionSeparator.ReplaceAllString(version, ".")},
+		{"$underscoreVersion", rxVersionSeparator.ReplaceAllString(version, "_")},
+		{"$dashVersion", rxVersionSeparator.ReplaceAllString(version, "-")},
+		{"$cleanVersion", rxVersionSeparator.ReplaceAllString(version, "")},
+		{"$majorVersion", part(0)},
+		{"$minorVersion", part(1)},
+		{"$patchVersion", part(2)},
+		{"$buildVersion", part(3)},
+	}
+	if i := strings.LastIndex(version, "-"); i >= 0 {
+		vars = append(vars, versionVariable{"$preReleaseVersion", version[i+1:]})
+	} else {
+		vars = append(vars, versionVariable{"$preReleaseVersion", version})
+	}
+	if m := rxMatchHead.FindStringSubmatch(version); m != nil {
+		vars = append(vars,
+			versionVariable{"$matchHead", m[1]},
+			versionVariable{"$matchTail", m[2]})
+	}
+	// The names are sorted so that the same variable is chosen every time
+	// for the same value.
+	for _, name := range slices.Sorted(maps.Keys(matches)) {
+		if name == "" || name == "0" {
+			continue
+		}
+		vars = append(vars, versionVariable{"$match" + strings.ToUpper(name[:1]) + name[1:], matches[name]})
+	}
+	return vars
+}
+
+// expandVersion replaces the variables in s with their values as Scoop does.
+func expandVersion(s string, vars []versionVariable) string {
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		values[v.name] = v.value
+	}
+	return substitute(s, values, false)
+}
+
+// isVersionSeparator reports whether c separates a version from
+// the other words in a URL
+func isVersionSeparator(c byte) bool {
+	return strings.IndexByte("/-_.+=@~", c) >= 0
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// versionStartsAt reports whether a version can start at s[i]:
+// after a separator, "%2F" (an escaped slash) or "v" after them.
+func versionStartsAt(s string, i int) bool {
+	if i > 0 && (s[i-1] == 'v' || s[i-1] == 'V') {
+		i--
+	}
+	if i == 0 || isVersionSeparator(s[i-1]) {
+		return true
+	}
+	return i >= 3 && strings.EqualFold(s[i-3:i], "%2F")
+}
+
+// versionEndsAt reports whether a version can end before s[j]. It must not
+// be followed by a digit nor by a dot and a digit, so that 1.0 does not
+// match with 1.0.1 nor 10.
+func versionEndsAt(s string, j int) bool {
+	if j >= len(s) {
+		return true
+	}
+	if isDigit(s[j]) {
+		return false
+	}
+	return s[j] != '.' || j+1 >= len(s) || !isDigit(s[j+1])
+}
+
+// tokenizeVersion replaces each occurrence of the version in s with
+// the variable whose value is the longest of those which match there.
+// The variables which may be a part of a word (only digits like
+// $majorVersion) are not used alone.
+func tokenizeVersion(s string, vars []versionVariable) string {
+	var buffer strings.Builder
+	for i := 0; i < len(s); {
+		var best *versionVariable
+		if versionStartsAt(s, i) {
+			for k := range vars {
+				v := &vars[k]
+				if !usableAlone(v.name) || v.value == "" || !strings.HasPrefix(s[i:], v.value) {
+					continue
+				}
+				if !versionEndsAt(s, i+len(v.value)) {
+					continue
+				}
+				if best == nil || len(v.value) > len(best.value) {
+					best = v
+				}
+			}
+		}
+		if best != nil {
+			buffer.WriteString(best.name)
+			i += len(best.value)
+			continue
+		}
+		buffer.WriteByte(s[i])
+		i++
+	}
+	return buffer.String()
+}
+
+// usableAlone reports whether the variable identifies the version
+// by itself. The parts of the version like "1" of $majorVersion are too
+// short to be distinguished from the other numbers in the URL.
+func usableAlone(name string) bool {
+	switch name {
+	case "$majorVersion", "$minorVersion", "$patchVersion", "$buildVersion",
+		"$preReleaseVersion", "$matchTail":
+		return false
+	}
+	return true
+}
+
+// templateUrl returns the URL for "autoupdate" in which the version is
+// replaced with the variables of Scoop. The path up to the repository
+// (prefix like "/OWNER/REPOS/"), the query and the fragment are kept as they are.
+// It is an error when expanding the variables does not give the URL back.
+func templateUrl(url, prefix string, vars []versionVariable) (string, error) {
+	rest, fragment, hasFragment := strings.Cut(url, "#")
+	rest, query, hasQuery := strings.Cut(rest, "?")
+
+	head := rest
+	if i := strings.Index(rest, "://"); i >= 0 {
+		if j := strings.IndexByte(rest[i+3:], '/'); j >= 0 {
+			head = rest[:i+3+j]
+		}
+	}
+	if prefix != "" {
+		if i := strings.Index(rest, prefix); i >= 0 && i >= len(head) {
+			head = rest[:i+len(prefix)]
+		}
+	}
+	result := head + tokenizeVersion(rest[len(head):], vars)
+	if hasQuery {
+		result += "?" + query
+	}
+	if hasFragment {
+		result += "#" + fragment
+	}
+	if result == url {
+		return "", fmt.Errorf("%s: the version is not found in the URL", url)
+	}
+	if back := expandVersion(result, vars); back != url {
+		return "", fmt.Errorf("%s: %s does not give the URL back (%s)", url, result, back)
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package scoop
+
+import (
+	"testing"
+)
+
+func TestTemplateUrl(t *testing.T) {
+	for _, tc := range []struct {
+		url     string
+		prefix  string
+		version string
+		expect  string
+	}{
+		{
+			url:     "https://github.com/hymkor/csvi/releases/download/v1.14.0/csvi-v1.14.0-windows-amd64.zip",
+			prefix:  "/hymkor/csvi/",
+			version: "1.14.0",
+			expect:  "https://github.com/hymkor/csvi/releases/download/v$version/csvi-v$version-windows-amd64.zip",
+		},
+		{
+			// the owner and the name like the version are not replaced
+			url:     "https://github.com/app2/app2/releases/download/v2/app2-2-win64.zip",
+			prefix:  "/app2/app2/",
+			version: "2",
+			expect:  "https://github.com/app2/app2/releases/download/v$version/app2-$version-win64.zip",
+		},
+		{
+			// 1.0 does not match with 1.0.1
+			url:     "https://example.com/foo/bar/1.0/foo-1.0.1-1.0.zip",
+			prefix:  "/foo/bar/",
+			version: "1.0",
+			expect:  "https://example.com/foo/bar/$version/foo-1.0.1-$version.zip",
+		},
+		{
+			url:     "https://example.com/o/r/releases/download/v1.2.3/app_1_2_3_x64.zip#/app.exe",
+			prefix:  "/o/r/",
+			version: "1.2.3",
+			expect:  "https://example.com/o/r/releases/download/v$version/app_$underscoreVersion_x64.zip#/app.exe",
+		},
+		{
+			url:     "https://example.com/o/r/releases/download/1.2.3-beta/app-1-2-3-beta.zip",
+			prefix:  "/o/r/",
+			version: "1.2.3-beta",
+			expect:  "https://example.com/o/r/releases/download/$version/app-$dashVersion.zip",
+		},
+	} {
+		result, err := templateUrl(tc.url, tc.prefix, versionVariables(tc.version, nil))
+		if err != nil {
+			t.Fatalf("%s: %s", tc.url, err.Error())
+		}
+		if result != tc.expect {
+			t.Fatalf("%s:\nexpect %s\nbut    %s", tc.url, tc.expect, result)
+		}
+	}
+	if _, err := templateUrl("https://example.com/o/r/latest/app.zip", "/o/r/", versionVariables("1.0", nil)); err == nil {
+		t.Fatal("expect an error for the URL without the version")
+	}
+}
@@ -0,0 +1,121 @@
+package scoop
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/hymkor/make-scoop-manifest/internal/ordered"
+)
+
+// Update replaces "version", "url" and "hash" of the manifest JSON
+// in source with those of manifest, and keeps the other fields and their order.
+// The architectures which manifest does not have are removed from
+// "architecture" and "autoupdate" with a warning to log.
+// When "url" and "hash" are the arrays of the files installed together,
+// the first ones are replaced and the others are kept.
+func Update(source []byte, manifest *Manifest, log io.Writer) ([]byte, error) {
+	var doc ordered.Object
+	if err := json.Unmarshal(source, &doc); err != nil {
+		return nil, err
+	}
+	if err := doc.Set("version", manifest.Version); err != nil {
+		return nil, err
+	}
+	if manifest.Archtectures == nil {
+		if err := setStrings(&doc, "url", manifest.UrlForAnyCPU); err != nil {
+			return nil, err
+		}
+		if err := setStrings(&doc, "hash", manifest.HashForAnyCPU); err != nil {
+			return nil, err
+		}
+		return format(&doc)
+	}
+	var archDoc ordered.Object
+	if raw, ok := doc.Get("architecture"); ok {
+		if err := json.Unmarshal(raw, &archDoc); err != nil {
+			return nil, fmt.Errorf("architecture: %w", err)
+		}
+	}
+	var autoUpdateDoc, autoUpdateArchDoc ordered.Object
+	if raw, ok := doc.Get("autoupdate"); ok {
+		if err := json.Unmarshal(raw, &autoUpdateDoc); err != nil {
+			return nil, fmt.Errorf("autoupdate: %w", err)
+		}
+		if raw, ok := autoUpdateDoc.Get("architecture"); ok {
+			if err := json.Unmarshal(raw, &autoUpdateArchDoc); err != nil {
+				return nil, fmt.Errorf("autoupdate.architecture: %w", err)
+			}
+		}
+	}
+	removed := false
+	for _, bits := range slices.Clone(archDoc.Keys()) {
+		if _, ok := manifest.Archtectures[bits]; !ok {
+			fmt.Fprintf(log, "Warning: %s is removed because the new release has no assets for it\n", bits)
+			archDoc.Delete(bits)
+			removed = true
+		}
+	}
+	for _, bits := range slices.Clone(autoUpdateArchDoc.Keys()) {
+		if _, ok := manifest.Archtectures[bits]; !ok {
+			autoUpdateArchDoc.Delete(bits)
+			removed = true
+		}
+	}
+	if removed && autoUpdateDoc.Has("architecture") {
+		if err := autoUpdateDoc.Set("architecture", &autoUpdateArchDoc); err != nil {
+			return nil, err
+		}
+		if err := doc.Set("autoupdate", &autoUpdateDoc); err != nil {
+			return nil, err
+		}
+	}
+	bitsList := make([]string, 0, len(manifest.Archtectures))
+	for bits := range manifest.Archtectures {
+		bitsList = append(bitsList, bits)
+	}
+	sort.Strings(bitsList)
+	for _, bits := range bitsList {
+		var arch1 ordered.Object
+		if raw, ok := archDoc.Get(bits); ok {
+			if err := json.Unmarshal(raw, &arch1); err != nil {
+				return nil, fmt.Errorf("architecture.%s: %w", bits, err)
+			}
+		}
+		if err := setStrings(&arch1, "url", manifest.Archtectures[bits].Url); err != nil {
+			return nil, err
+		}
+		if err := setStrings(&arch1, "hash", manifest.Archtectures[bits].Hash); err != nil {
+			return nil, err
+		}
+		if err := archDoc.Insert(bits, &arch1, keyOrder); err != nil {
+			return nil, err
+		}
+	}
+	if err := doc.Set("architecture", &archDoc); err != nil {
+		return nil, err
+	}
+	return format(&doc)
+}
+
+// setStrings sets values to key of obj. When the current value is
+// a longer array, the elements after values are kept.
+func setStrings(obj *ordered.Object, key string, values Strings) error {
+	if raw, ok := obj.Get(key); ok {
+		var current Strings
+		if err := json.Unmarshal(raw, &current); err == nil && len(current) > len(values) {
+			values = append(slices.Clone(values), current[len(values):]...)
+		}
+	}
+	return obj.Set(key, values)
+}
+
+func format(doc *ordered.Object) ([]byte, error) {
+	jsonBin, err := ordered.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return withCRLF(jsonBin)
+}
@@ -0,0 +1,192 @@
+package scoop
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+//go:embed schema.json
+var schemaJson []byte
+
+// schemaUrl is "$id" of the bundled schema. It is a modified copy of
+// schema.json of Scoop, so it has its own URL. The changes are told
+// in "$comment" of it.
+const schemaUrl = "https://raw.githubusercontent.com/hymkor/make-scoop-manifest/master/scoop/schema.json"
+
+// schemaCompiler returns the function which compiles the bundled schema
+// only once with the regexp engine.
+func schemaCompiler(engine jsonschema.RegexpEngine) func() (*jsonschema.Schema, error) {
+	return sync.OnceValues(func() (*jsonschema.Schema, error) {
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaJson))
+		if err != nil {
+			return nil, err
+		}
+		c := jsonschema.NewCompiler()
+		c.UseRegexpEngine(engine)
+		if err := c.AddResource(schemaUrl, doc); err != nil {
+			return nil, err
+		}
+		return c.Compile(schemaUrl)
+	})
+}
+
+// compileSchema is used by Validate. The regular expressions which Go can
+// not compile are accepted.
+var compileSchema = schemaCompiler(dotnetRegexp)
+
+// compileGoSchema is used by RegexpWarnings to find the regular
+// expressions which Go can not compile.
+var compileGoSchema = schemaCompiler(nil)
+
+// anyRegexp is used for the regular expressions Go can not compile
+type anyRegexp string
+
+func (r anyRegexp) String() string { return string(r) }
+
+func (anyRegexp) MatchString(string) bool { return true }
+
+// dotnetRegexp compiles the regular expressions for "format": "regex".
+// Scoop runs them with .NET, which has the features regexp of Go does not
+// have like lookbehind, so they are not reported as invalid.
+// RegexpWarnings reports them instead.
+func dotnetRegexp(s string) (jsonschema.Regexp, error) {
+	if rx, err := regexp.Compile(s); err == nil {
+		return rx, nil
+	}
+	return anyRegexp(s), nil
+}
+
+// printer is used for the messages of the violations
+var printer = message.NewPrinter(language.English)
+
+// Violation is a part of the manifest which does not follow the schema
+type Violation struct {
+	// Path is the JSON pointer to the value like "/architecture/64bit/url".
+	// It is "" for the whole manifest.
+	Path    string
+	Message string
+}
+
+func (v *Violation) String() string {
+	if v.Path == "" {
+		return "(root): " + v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError is returned by Validate for the invalid manifest
+type ValidationError struct {
+	Violations []*Violation
+}
+
+func (e *ValidationError) Error() string {
+	var s strings.Builder
+	s.WriteString("the manifest does not follow the schema of Scoop:")
+	for _, v := range e.Violations {
+		s.WriteString("\n  ")
+		s.WriteString(v.String())
+	}
+	return s.String()
+}
+
+// Validate checks the manifest JSON with the schema of Scoop bundled.
+// It returns *ValidationError which has the violations for the invalid one.
+func Validate(jsonBin []byte) error {
+	schema, err := compileSchema()
+	if err != nil {
+		return fmt.Errorf("schema.json: %w", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(jsonBin))
+	if err != nil {
+		return err
+	}
+	err = schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+	var violations []*Violation
+	eachLeaf(ve, func(e *jsonschema.ValidationError) {
+		path := jsonPointer(e.InstanceLocation)
+		message := e.ErrorKind.LocalizedString(printer)
+		// The alternatives of "anyOf" for the same value are joined
+		if i := slices.IndexFunc(violations, func(v *Violation) bool { return v.Path == path }); i >= 0 {
+			if !strings.Contains(violations[i].Message, message) {
+				violations[i].Message += ", or " + message
+			}
+			return
+		}
+		violations = append(violations, &Violation{Path: path, Message: message})
+	})
+	sortViolations(violations)
+	return &ValidationError{Violations: violations}
+}
+
+// RegexpWarnings returns the regular expressions in the manifest JSON
+// which regexp of Go can not compile. They are not violations because
+// Scoop runs them with .NET, but they are not checked by Validate.
+func RegexpWarnings(jsonBin []byte) ([]*Violation, error) {
+	schema, err := compileGoSchema()
+	if err != nil {
+		return nil, fmt.Errorf("schema.json: %w", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(jsonBin))
+	if err != nil {
+		return nil, err
+	}
+	ve, ok := schema.Validate(doc).(*jsonschema.ValidationError)
+	if !ok {
+		return nil, nil
+	}
+	var warnings []*Violation
+	eachLeaf(ve, func(e *jsonschema.ValidationError) {
+		if f, ok := e.ErrorKind.(*kind.Format); ok && f.Want == "regex" {
+			warnings = append(warnings, &Violation{
+				Path:    jsonPointer(e.InstanceLocation),
+				Message: f.LocalizedString(printer),
+			})
+		}
+	})
+	sortViolations(warnings)
+	return warnings, nil
+}
+
+// eachLeaf calls fn with the errors which have no causes under e
+func eachLeaf(e *jsonschema.ValidationError, fn func(*jsonschema.ValidationError)) {
+	if len(e.Causes) == 0 {
+		fn(e)
+		return
+	}
+	for _, cause := range e.Causes {
+		eachLeaf(cause, fn)
+	}
+}
+
+func sortViolations(violations []*Violation) {
+	slices.SortStableFunc(violations, func(a, b *Violation) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+}
+
+// jsonPointer returns the JSON pointer (RFC 6901) to the value
+func jsonPointer(tokens []string) string {
+	var s strings.Builder
+	for _, token := range tokens {
+		s.WriteByte('/')
+		s.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return s.String()
+}
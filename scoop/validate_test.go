@@ -0,0 +1,72 @@
+package scoop
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := `{
+    "version": "1.0.0",
+    "description": "app",
+    "homepage": "https://github.com/o/app",
+    "license": "MIT",
+    "architecture": {
+        "64bit": {
+            "url": "https://github.com/o/app/releases/download/v1.0.0/app-windows-amd64.zip",
+            "hash": "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+        }
+    },
+    "bin": "app.exe",
+    "checkver": {
+        "github": "https://github.com/o/app",
+        "regex": "(?<=/tag/)v([\\d.]+)"
+    },
+    "autoupdate": {
+        "architecture": {
+            "64bit": {
+                "url": "https://github.com/o/app/releases/download/v$version/app-windows-amd64.zip"
+            }
+        }
+    }
+}`
+	if err := Validate([]byte(valid)); err != nil {
+		t.Fatal(err.Error())
+	}
+	// Go can not compile the lookbehind
+	warnings, err := RegexpWarnings([]byte(valid))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(warnings) != 1 || warnings[0].Path != "/checkver/regex" {
+		t.Fatalf("expect the warning at /checkver/regex, but %v", warnings)
+	}
+
+	invalid := `{
+    "version": "1.0.0",
+    "description": "app",
+    "homepage": "https://github.com/o/app",
+    "license": "MIT",
+    "architecture": {},
+    "bin": null
+}`
+	err = Validate([]byte(invalid))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("expect ValidationError, but %v", err)
+	}
+	var paths []string
+	for _, v := range ve.Violations {
+		paths = append(paths, v.Path)
+	}
+	if expect := []string{"/architecture", "/bin"}; !slices.Equal(paths, expect) {
+		t.Fatalf("expect the violations at %v, but %v", expect, ve.Violations)
+	}
+}
+
+func TestJsonPointer(t *testing.T) {
+	if p := jsonPointer([]string{"architecture", "64bit", "a/b~c"}); p != "/architecture/64bit/a~1b~0c" {
+		t.Fatalf("unexpected pointer %q", p)
+	}
+}
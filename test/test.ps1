@@ -3,7 +3,7 @@ $testset = @(
         'goawk.json' ),
     @('-anycpu -g zat-kaoru-hayama/yShowVer -D',
         'yShowVer.json'),
-    @('-p -license "MIT License" -g mattn/twty -D',
+    @('-p -license MIT -g mattn/twty -D',
         'twty.json'),
     @('-D -g hymkor/Download-Count.ps1 -binpattern "*.ps1" -anycpu',
         "Download-Count.ps1.json"),
@@ -15,7 +15,7 @@ $success = 0
 $failure = 0
 
 foreach($p in $testset){
-    $expect = $p[1]
+    $expect = (Join-Path "..\testdata" $p[1])
     $result = (Join-Path $env:TEMP $p[1])
 
     Write-Host "expect:" $expect
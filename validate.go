@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hymkor/make-scoop-manifest/scoop"
+)
+
+// warnInvalid reports the violations of the schema of Scoop and the regular
+// expressions Go can not compile in the manifest generated as warnings.
+// The manifest is written even if it is invalid.
+func warnInvalid(name string, jsonBin []byte) {
+	warnings, _ := scoop.RegexpWarnings(jsonBin)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", name, w.String())
+	}
+	err := scoop.Validate(jsonBin)
+	if err == nil {
+		return
+	}
+	var ve *scoop.ValidationError
+	if !errors.As(err, &ve) {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", name, err.Error())
+		return
+	}
+	for _, v := range ve.Violations {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", name, v.String())
+	}
+}
+
+// validateCommand runs "validate FILE...", which checks the manifests
+// with the schema of Scoop and prints the violations with the JSON pointers
+// to the values. The regular expressions Go can not compile are printed
+// as warnings, and the files are not counted as invalid for them.
+func validateCommand(args []string, stdout io.Writer) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: %s validate FILE...", os.Args[0])
+	}
+	var invalid int
+	for _, fname := range args {
+		jsonBin, err := os.ReadFile(fname)
+		if err == nil {
+			var warnings []*scoop.Violation
+			warnings, err = scoop.RegexpWarnings(jsonBin)
+			for _, w := range warnings {
+				fmt.Fprintf(stdout, "%s: warning: %s\n", fname, w.String())
+			}
+		}
+		if err == nil {
+			err = scoop.Validate(jsonBin)
+		}
+		if err == nil {
+			continue
+		}
+		invalid++
+		var ve *scoop.ValidationError
+		if !errors.As(err, &ve) {
+			fmt.Fprintf(stdout, "%s: %s\n", fname, err.Error())
+			continue
+		}
+		for _, v := range ve.Violations {
+			fmt.Fprintf(stdout, "%s: %s\n", fname, v.String())
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files are invalid", invalid, len(args))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hymkor/make-scoop-manifest/scoop"
+)
+
+// makeVersions writes the manifests of the releases into the directory
+// given with -versions as APP@VERSION.json for the versions bucket of Scoop.
+// The releases without assets for Windows and the files which already exist
+// are skipped.
+func makeVersions(ctx context.Context, g *scoop.Generator, repo string) error {
+	releases, err := g.Releases(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*flagVersionsDir, 0755); err != nil {
+		return err
+	}
+	var written, exists, skipped, failed []string
+	for i, release := range releases {
+		if *flagLast > 0 && i >= *flagLast {
+			break
+		}
+		fname := filepath.Join(*flagVersionsDir, fmt.Sprintf("%s@%s.json", release.Repos(), release.Version()))
+		if _, err := os.Stat(fname); err == nil {
+			exists = append(exists, release.Tag)
+			continue
+		}
+		manifest, err := g.GenerateRelease(ctx, release)
+		if errors.Is(err, scoop.ErrAssetsNotFound) {
+			skipped = append(skipped, release.Tag)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", release.Tag, err.Error())
+			failed = append(failed, release.Tag)
+			continue
+		}
+		jsonBin, err := manifest.Format()
+		if err != nil {
+			return err
+		}
+		warnInvalid(fname, jsonBin)
+		if err := os.WriteFile(fname, jsonBin, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Write", fname)
+		written = append(written, release.Tag)
+	}
+	fmt.Fprintf(os.Stderr, "%d written, %d already existed\n", len(written), len(exists))
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "%d skipped because no assets for Windows were found: %s\n",
+			len(skipped), strings.Join(skipped, " "))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d failed: %s", len(failed), strings.Join(failed, " "))
+	}
+	return nil
+}